@@ -2,9 +2,12 @@ package main
 
 import (
 	"context"
-	"database/sql"
-
+	"flag"
+	"fmt"
 	"log"
+	"os"
+	"strconv"
+	"text/tabwriter"
 
 	"github.com/gin-gonic/gin"
 	"github.com/iamlucif3r/sarjan/internal/config"
@@ -14,74 +17,257 @@ import (
 	"github.com/iamlucif3r/sarjan/pkg"
 )
 
-var Config *types.Config
-var Db *sql.DB
+func main() {
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "config":
+			os.Exit(configCommand(os.Args[2:]))
+		case "migrate":
+			os.Exit(migrateCommand(os.Args[2:]))
+		case "seed":
+			os.Exit(seedCommand(os.Args[2:]))
+		}
+	}
+	flags := flag.NewFlagSet("sarjan", flag.ExitOnError)
+	configFile := flags.String("config", "", "YAML or TOML config file (default $CONFIG_FILE, then sarjan.yaml, sarjan.yml or sarjan.toml)")
+	flags.Parse(os.Args[1:])
 
-func init() {
 	log.Println("Initializing SARJAN...")
-	log.Println("Initializing configuration...")
-	Config = &types.Config{}
-
-	err := config.SetConfig(Config)
+	cfg, err := config.Load(*configFile)
+	if err == nil {
+		if problems := checkConfigFiles(cfg); len(problems) > 0 {
+			err = problems
+		}
+	}
 	if err != nil {
-		log.Printf("Error setting configuration: %v\n", err)
-		return
+		log.Fatalf("%v\nRun `sarjan config check` to see the full configuration.", err)
 	}
-	Db, err = database.ConnectDB(*Config)
+
+	db, err := database.ConnectDB(cfg)
 	if err != nil {
-		log.Printf("Error connecting to database: %v\n", err)
-		return
+		log.Fatalf("Error connecting to database: %v", err)
+	}
+	if err := prepareDatabase(db, cfg.MigrateOnStart); err != nil {
+		log.Fatalf("Database is not ready: %v", err)
 	}
 	log.Println("Configuration initialized successfully.")
-}
 
-func main() {
+	app, err := pkg.NewApp(cfg, db)
+	if err != nil {
+		log.Fatal(err)
+	}
+	go app.Prompts.Watch(context.Background(), cfg.PromptReloadInterval)
 
-	router := gin.Default()
-	router.GET("/", func(c *gin.Context) {
-		c.JSON(200, gin.H{
-			"message": "SARJAN : Smart Assistant for Real-time Journey from news to Actionable Narratives",
-		})
-	})
+	queue := pkg.NewJobQueue(app, 64)
+	queue.Start(context.Background(), 1)
+	if err := queue.Resume(); err != nil {
+		log.Printf("Error resuming jobs: %v\n", err)
+	}
 
-	router.GET("/health", func(c *gin.Context) {
-		c.JSON(200, gin.H{
-			"message": "Alive",
-		})
-	})
+	router := (&server{app: app, queue: queue}).routes()
+	gin.SetMode(gin.ReleaseMode)
+	router.Run(":4446")
+}
 
-	router.POST("/generate", func(c *gin.Context) {
+// prepareDatabase brings SARJAN's tables up to date, or refuses to start
+// when migrations are pending and migrate is off, and checks the upstream
+// articles table.
+func prepareDatabase(db database.Repository, migrate bool) error {
+	if migrate {
+		applied, err := db.MigrateUp()
+		for _, m := range applied {
+			log.Printf("Applied migration %d_%s", m.Version, m.Name)
+		}
+		if err != nil {
+			return err
+		}
+	} else {
+		pending, err := db.PendingMigrations()
+		if err != nil {
+			return err
+		}
+		if len(pending) > 0 {
+			return fmt.Errorf("%d migration(s) pending; run `sarjan migrate up` or set MIGRATE_ON_START=true", len(pending))
+		}
+	}
+	missing, err := db.CheckUpstream()
+	if err != nil {
+		return err
+	}
+	if len(missing) > 0 {
+		log.Printf("[WARN] articles table has no %v column(s); the since and source filters will fail", missing)
+	}
+	return nil
+}
 
-		log.Println("[INFO] Starting content generation process...")
+// configCommand runs `sarjan config check`, which prints the configuration
+// with secrets masked and every problem with it, including the brands file
+// and prompt templates it points at.
+func configCommand(args []string) int {
+	if len(args) == 0 || args[0] != "check" {
+		fmt.Fprintln(os.Stderr, "usage: sarjan config check [-config file]")
+		return 2
+	}
+	flags := flag.NewFlagSet("config check", flag.ExitOnError)
+	configFile := flags.String("config", "", "YAML or TOML config file")
+	flags.Parse(args[1:])
 
-		db := database.DB
-		ctx := context.Background()
+	loaded := config.Inspect(*configFile)
+	loaded.Problems = append(loaded.Problems, checkConfigFiles(loaded.Config)...)
+	loaded.Report(os.Stdout)
+	if len(loaded.Problems) > 0 {
+		return 1
+	}
+	return 0
+}
 
-		articles, err := pkg.FetchTopRankedArticles(db, 1)
-		if err != nil {
-			log.Fatalf("Failed to fetch articles: %v", err)
+// checkConfigFiles checks what the settings point at, which the config
+// package leaves alone: the PDF emoji font, the render formats, the brands
+// file and the prompt templates.
+func checkConfigFiles(cfg types.Config) config.Problems {
+	var problems config.Problems
+	renderers, err := utils.NewRenderers(cfg.PDFEmojiFont)
+	if err != nil {
+		problems = append(problems, "PDF_EMOJI_FONT: "+err.Error())
+		renderers, _ = utils.NewRenderers("")
+	}
+	for _, format := range cfg.RenderFormats {
+		if _, err := renderers.Get(format); err != nil {
+			problems = append(problems, "RENDER_FORMATS: "+err.Error())
 		}
-		log.Println("[INFO] Fetched ", len(articles), " articles from database")
+	}
+	if _, err := pkg.LoadBrands(cfg.BrandsFile, cfg.DefaultBrand, cfg.DiscordWebhookURL, cfg.Env); err != nil {
+		problems = append(problems, err.Error())
+	}
+	if _, err := pkg.NewPromptRegistry(cfg.PromptsDir, cfg.PromptPins); err != nil {
+		problems = append(problems, err.Error())
+	}
+	return problems
+}
 
-		bundle, err := pkg.GenerateContentIdeas(ctx, articles, *Config)
-		if err != nil {
-			log.Println("[Error] Failed to generate content ideas:", err)
+// migrateCommand runs `sarjan migrate up|down|status`.
+func migrateCommand(args []string) int {
+	usage := func() int {
+		fmt.Fprintln(os.Stderr, "usage: sarjan migrate up|down|status [-config file] [-steps n]")
+		return 2
+	}
+	if len(args) == 0 {
+		return usage()
+	}
+	flags := flag.NewFlagSet("migrate "+args[0], flag.ExitOnError)
+	configFile := flags.String("config", "", "YAML or TOML config file")
+	steps := flags.Int("steps", 1, "number of migrations to revert (down only)")
+	flags.Parse(args[1:])
+	if *steps < 1 {
+		return usage()
+	}
+
+	cfg, err := config.Load(*configFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\nRun `sarjan config check` to see the full configuration.\n", err)
+		return 1
+	}
+	db, err := database.ConnectDB(cfg)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	defer db.Close()
 
+	switch args[0] {
+	case "up":
+		applied, err := db.MigrateUp()
+		for _, m := range applied {
+			fmt.Printf("applied %d_%s\n", m.Version, m.Name)
 		}
-		log.Println("[INFO] Generated content ideas successfully")
-		err = utils.GenerateContentIdeasPDF(bundle, "output/content_ideas.pdf")
 		if err != nil {
-			log.Println("Failed to generate PDF:", err)
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		if len(applied) == 0 {
+			fmt.Println("already up to date")
+		}
+	case "down":
+		reverted, err := db.MigrateDown(*steps)
+		for _, m := range reverted {
+			fmt.Printf("reverted %d_%s\n", m.Version, m.Name)
 		}
-
-		err = utils.SendPDFToDiscord(Config.DiscordWebhookURL, "output/content_ideas.pdf")
 		if err != nil {
-			log.Println("Failed to send Markdown to Discord:", err)
-		} else {
-			log.Println("[Info] Sent content ideas to Discord successfully!")
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		if len(reverted) == 0 {
+			fmt.Println("nothing to revert")
+		}
+	case "status":
+		states, err := db.MigrationStatus()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "VERSION\tNAME\tAPPLIED")
+		for _, s := range states {
+			applied := "pending"
+			switch {
+			case s.Unknown:
+				applied = s.AppliedAt.Format("2006-01-02 15:04:05") + " (not in this build)"
+			case s.AppliedAt != nil:
+				applied = s.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Fprintf(tw, "%s\t%s\t%s\n", strconv.Itoa(s.Version), s.Name, applied)
 		}
+		tw.Flush()
+		if _, err := db.CheckUpstream(); err != nil {
+			fmt.Fprintln(os.Stderr, "\n"+err.Error())
+			return 1
+		}
+	default:
+		return usage()
+	}
+	return 0
+}
 
-	})
-	gin.SetMode(gin.ReleaseMode)
-	router.Run(":4446")
+// seedCommand runs `sarjan seed`, which fills a SQLite database's articles
+// table from a fixtures file, or from the bundled sample articles.
+func seedCommand(args []string) int {
+	flags := flag.NewFlagSet("seed", flag.ExitOnError)
+	configFile := flags.String("config", "", "YAML or TOML config file")
+	file := flags.String("file", "", "JSON fixtures file (default: the bundled sample articles)")
+	flags.Parse(args)
+
+	cfg, err := config.Load(*configFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\nRun `sarjan config check` to see the full configuration.\n", err)
+		return 1
+	}
+	db, err := database.ConnectDB(cfg)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	defer db.Close()
+	local, ok := db.(*database.SQLite)
+	if !ok {
+		fmt.Fprintln(os.Stderr, "sarjan seed only writes to SQLite databases; on Postgres the articles come from TRIKAL")
+		return 1
+	}
+
+	var articles []database.ArticleFixture
+	if *file != "" {
+		articles, err = database.LoadFixtures(*file)
+	} else {
+		articles, err = database.DefaultFixtures()
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	added, err := local.SeedArticles(articles)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	fmt.Printf("added %d of %d article(s)\n", added, len(articles))
+	return 0
 }
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/iamlucif3r/sarjan/internal/types"
+)
+
+// bindArticleQuery overlays article selection query parameters onto q, so
+// callers can use either a JSON body or a plain query string.
+//
+//	?limit=3&min_score=7.5&since=48h&source=a,b&include=rce&exclude=crypto&exclude_used=true
+func bindArticleQuery(c *gin.Context, q *types.ArticleQuery) error {
+	if v := c.Query("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			return fmt.Errorf("invalid limit %q", v)
+		}
+		q.Limit = n
+	}
+	if v := c.Query("min_score"); v != "" {
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return fmt.Errorf("invalid min_score %q", v)
+		}
+		q.MinScore = &f
+	}
+	if v := c.Query("since"); v != "" {
+		t, err := parseSince(v)
+		if err != nil {
+			return err
+		}
+		q.PublishedAfter = &t
+	}
+	if v := queryList(c, "source"); len(v) > 0 {
+		q.Sources = v
+	}
+	if v := queryList(c, "include"); len(v) > 0 {
+		q.Include = v
+	}
+	if v := queryList(c, "exclude"); len(v) > 0 {
+		q.Exclude = v
+	}
+	if v := c.Query("exclude_used"); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return fmt.Errorf("invalid exclude_used %q", v)
+		}
+		q.ExcludeUsed = b
+	}
+	if q.Limit < 0 {
+		return fmt.Errorf("limit must not be negative")
+	}
+	return nil
+}
+
+// parseSince accepts either a lookback window such as "48h" or an RFC 3339
+// timestamp.
+func parseSince(v string) (time.Time, error) {
+	if d, err := time.ParseDuration(v); err == nil {
+		if d <= 0 {
+			return time.Time{}, fmt.Errorf("since window must be positive")
+		}
+		return time.Now().Add(-d).UTC(), nil
+	}
+	t, err := time.Parse(time.RFC3339, v)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid since %q: want a duration like 48h or an RFC 3339 time", v)
+	}
+	return t, nil
+}
+
+// queryList reads a parameter given either repeated or comma-separated.
+func queryList(c *gin.Context, key string) []string {
+	var out []string
+	for _, v := range c.QueryArray(key) {
+		for _, part := range strings.Split(v, ",") {
+			if part = strings.TrimSpace(part); part != "" {
+				out = append(out, part)
+			}
+		}
+	}
+	return out
+}
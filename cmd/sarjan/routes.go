@@ -0,0 +1,290 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/iamlucif3r/sarjan/internal/types"
+	"github.com/iamlucif3r/sarjan/pkg"
+)
+
+// server is the HTTP API. Handlers reach every dependency through app.
+type server struct {
+	app   *pkg.App
+	queue *pkg.JobQueue
+}
+
+func (s *server) routes() *gin.Engine {
+	router := gin.Default()
+	router.GET("/", func(c *gin.Context) {
+		c.JSON(200, gin.H{
+			"message": "SARJAN : Smart Assistant for Real-time Journey from news to Actionable Narratives",
+		})
+	})
+
+	// /health/live only says the process is serving; /health/ready checks
+	// the dependencies a job needs. /health is kept for older probes.
+	live := func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{
+			"status":  types.HealthOK,
+			"message": "Alive",
+		})
+	}
+	router.GET("/health", live)
+	router.GET("/health/live", live)
+
+	router.GET("/health/ready", func(c *gin.Context) {
+		report := s.app.Ready(c.Request.Context())
+		status := http.StatusOK
+		if report.Status != types.HealthOK {
+			status = http.StatusServiceUnavailable
+		}
+		c.JSON(status, report)
+	})
+
+	router.GET(pkg.ArtifactPath+"/:brand/*file", func(c *gin.Context) {
+		brand, err := s.app.Brands.Get(c.Param("brand"))
+		if err != nil || c.Param("brand") == "" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "unknown brand"})
+			return
+		}
+		c.FileFromFS(c.Param("file"), http.Dir(brand.OutputDir))
+	})
+
+	router.GET("/brands", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"brands": s.app.Brands.List()})
+	})
+
+	router.POST("/generate", func(c *gin.Context) {
+		if s.app.Store == nil {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "database unavailable"})
+			return
+		}
+
+		var req types.GenerateRequest
+		if c.Request.ContentLength != 0 {
+			if err := c.ShouldBindJSON(&req); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+		}
+		if err := bindArticleQuery(c, &req.Articles); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if v := c.Query("brand"); v != "" {
+			req.Brand = v
+		}
+		if _, err := s.app.Brands.Get(req.Brand); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if v := queryList(c, "platforms"); len(v) > 0 {
+			req.Platforms = v
+		}
+		if _, err := pkg.ResolveRequirements(req.Platforms, req.Counts); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if v := queryList(c, "formats"); len(v) > 0 {
+			req.Formats = v
+		}
+		for _, format := range req.Formats {
+			if _, err := s.app.Renderers.Get(format); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+		}
+
+		job, err := s.queue.Submit(req)
+		if err != nil {
+			log.Println("[Error] Failed to queue content generation:", err)
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error(), "job_id": job.ID})
+			return
+		}
+		log.Println("[INFO] Queued content generation job", job.ID)
+
+		c.JSON(http.StatusAccepted, gin.H{
+			"job_id": job.ID,
+			"state":  job.State,
+			"status": "/jobs/" + strconv.FormatInt(job.ID, 10),
+		})
+	})
+
+	router.POST("/rank", func(c *gin.Context) {
+		if s.app.Store == nil {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "database unavailable"})
+			return
+		}
+
+		// candidates is how many articles are fetched for ranking; a limit,
+		// in the body or the query, means the same and may be sent instead.
+		req := struct {
+			Articles   types.ArticleQuery `json:"articles"`
+			Brand      string             `json:"brand"`
+			Candidates *int               `json:"candidates"`
+			BatchSize  int                `json:"batch_size"`
+			Winners    int                `json:"winners"`
+		}{BatchSize: s.app.Config.RerankBatchSize, Winners: s.app.Config.RerankWinners}
+		if c.Request.ContentLength != 0 {
+			if err := c.ShouldBindJSON(&req); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+		}
+		if err := bindArticleQuery(c, &req.Articles); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		candidates := s.app.Config.RerankCandidates
+		switch {
+		case req.Candidates != nil && req.Articles.Limit > 0 && *req.Candidates != req.Articles.Limit:
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("limit (%d) and candidates (%d) disagree; send one of them", req.Articles.Limit, *req.Candidates)})
+			return
+		case req.Candidates != nil:
+			candidates = *req.Candidates
+		case req.Articles.Limit > 0:
+			candidates = req.Articles.Limit
+		}
+		if candidates < 1 || req.BatchSize < 2 || req.Winners < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "candidates and winners must be positive and batch_size at least 2"})
+			return
+		}
+
+		if v := c.Query("brand"); v != "" {
+			req.Brand = v
+		}
+		brand, err := s.app.Brands.Get(req.Brand)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		judgePrompt, err := s.app.Prompts.Get(pkg.PromptJudgeArticles)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		req.Articles.Limit = candidates
+		ranked, err := pkg.RankTopCandidates(c.Request.Context(), s.app.Store, s.app.LLM, req.Articles, pkg.RankOptions{BatchSize: req.BatchSize, Prompt: judgePrompt, Brand: brand}, nil)
+		if err != nil {
+			log.Println("[Error] Failed to rank articles:", err)
+			c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"winners": ranked[:min(req.Winners, len(ranked))],
+			"ranked":  ranked,
+		})
+	})
+
+	router.GET("/prompts", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"prompts": s.app.Prompts.List()})
+	})
+
+	router.POST("/prompts/reload", func(c *gin.Context) {
+		if err := s.app.Prompts.Reload(); err != nil {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"prompts": s.app.Prompts.List()})
+	})
+
+	router.GET("/jobs/:id", func(c *gin.Context) {
+		if s.app.Store == nil {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "database unavailable"})
+			return
+		}
+
+		id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid job id"})
+			return
+		}
+		job, err := s.app.Store.GetJob(id)
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "job not found"})
+			return
+		}
+		if err != nil {
+			log.Println("[Error] Failed to fetch job:", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch job"})
+			return
+		}
+		c.JSON(http.StatusOK, job)
+	})
+
+	router.GET("/jobs/:id/deliveries", func(c *gin.Context) {
+		if s.app.Store == nil {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "database unavailable"})
+			return
+		}
+
+		id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid job id"})
+			return
+		}
+		attempts, err := s.app.Store.ListDeliveryAttempts(id)
+		if err != nil {
+			log.Println("[Error] Failed to fetch delivery attempts:", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch delivery attempts"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"deliveries": attempts})
+	})
+
+	router.GET("/bundles", func(c *gin.Context) {
+		if s.app.Store == nil {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "database unavailable"})
+			return
+		}
+
+		limit, err := strconv.Atoi(c.DefaultQuery("limit", "20"))
+		if err != nil || limit < 1 || limit > 100 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be between 1 and 100"})
+			return
+		}
+		offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
+		if err != nil || offset < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid offset"})
+			return
+		}
+		bundles, err := s.app.Store.ListBundles(limit, offset)
+		if err != nil {
+			log.Println("[Error] Failed to list bundles:", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list bundles"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"bundles": bundles, "limit": limit, "offset": offset})
+	})
+
+	router.GET("/bundles/:id", func(c *gin.Context) {
+		if s.app.Store == nil {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "database unavailable"})
+			return
+		}
+
+		id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid bundle id"})
+			return
+		}
+		bundle, err := s.app.Store.GetBundle(id)
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "bundle not found"})
+			return
+		}
+		if err != nil {
+			log.Println("[Error] Failed to fetch bundle:", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch bundle"})
+			return
+		}
+		c.JSON(http.StatusOK, bundle)
+	})
+
+	return router
+}
@@ -0,0 +1,236 @@
+package config
+
+import (
+	"fmt"
+	"io"
+	"net/mail"
+	"net/url"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/iamlucif3r/sarjan/internal/types"
+)
+
+// validate checks the struct tag rules on every field, then the rules
+// that involve more than one setting. It only looks at the values; whether
+// the files and formats they name exist is checked by the caller.
+func validate(cfg types.Config) Problems {
+	var problems Problems
+	v := reflect.ValueOf(cfg)
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field, value := t.Field(i), v.Field(i)
+		env := field.Tag.Get("env")
+		if env == "" {
+			continue
+		}
+		if field.Tag.Get("required") == "true" && value.IsZero() {
+			problems = append(problems, env+" is required")
+			continue
+		}
+		if min := field.Tag.Get("min"); min != "" {
+			if problem := checkMin(env, value, min); problem != "" {
+				problems = append(problems, problem)
+			}
+		}
+		if oneof := field.Tag.Get("oneof"); oneof != "" && value.String() != "" {
+			allowed := strings.Fields(oneof)
+			if !contains(allowed, value.String()) {
+				problems = append(problems, fmt.Sprintf("%s must be one of %s (got %q)", env, strings.Join(allowed, ", "), value.String()))
+			}
+		}
+		if kind := field.Tag.Get("url"); kind != "" && value.String() != "" {
+			if problem := checkURL(env, value.String(), kind); problem != "" {
+				problems = append(problems, problem)
+			}
+		}
+	}
+
+	if cfg.LLMBaseURL == "" {
+		problems = append(problems, "LLM_BASE_URL (or OLLAMA_URL) is required")
+	}
+	if cfg.DBMaxIdleConns > cfg.DBMaxOpenConns {
+		problems = append(problems, fmt.Sprintf("DB_MAX_IDLE_CONNS (%d) must not exceed DB_MAX_OPEN_CONNS (%d)", cfg.DBMaxIdleConns, cfg.DBMaxOpenConns))
+	}
+	if cfg.WebhookRetryMax < cfg.WebhookRetryBase {
+		problems = append(problems, fmt.Sprintf("WEBHOOK_RETRY_MAX (%s) must not be shorter than WEBHOOK_RETRY_BASE (%s)", cfg.WebhookRetryMax, cfg.WebhookRetryBase))
+	}
+	if cfg.RerankWinners > cfg.RerankCandidates {
+		problems = append(problems, fmt.Sprintf("RERANK_WINNERS (%d) must not exceed RERANK_CANDIDATES (%d)", cfg.RerankWinners, cfg.RerankCandidates))
+	}
+	if len(cfg.RenderFormats) == 0 {
+		problems = append(problems, "RENDER_FORMATS needs at least one format")
+	}
+	if cfg.SMTPPort > 65535 {
+		problems = append(problems, fmt.Sprintf("SMTP_PORT %d is not a valid port", cfg.SMTPPort))
+	}
+	if cfg.SMTPHost != "" && cfg.SMTPFrom == "" {
+		problems = append(problems, "SMTP_FROM is required when SMTP_HOST is set")
+	}
+	if cfg.SMTPFrom != "" {
+		if _, err := mail.ParseAddress(cfg.SMTPFrom); err != nil {
+			problems = append(problems, fmt.Sprintf("SMTP_FROM %q is not an email address", cfg.SMTPFrom))
+		}
+	}
+	return problems
+}
+
+func checkMin(env string, value reflect.Value, min string) string {
+	if value.Type() == durationType {
+		limit, err := time.ParseDuration(min)
+		if err == nil && time.Duration(value.Int()) < limit {
+			return fmt.Sprintf("%s must be at least %s (got %s)", env, limit, time.Duration(value.Int()))
+		}
+		return ""
+	}
+	limit, err := strconv.ParseInt(min, 10, 64)
+	if err == nil && value.Int() < limit {
+		return fmt.Sprintf("%s must be at least %d (got %d)", env, limit, value.Int())
+	}
+	return ""
+}
+
+// checkURL accepts http(s) URLs for "http", and for "database" postgres
+// URLs, key=value connection strings or sqlite:<path>.
+func checkURL(env, raw, kind string) string {
+	if path, ok := strings.CutPrefix(raw, "sqlite:"); kind == "database" && ok {
+		if strings.TrimPrefix(path, "//") == "" {
+			return fmt.Sprintf("%s: sqlite: needs a file path, e.g. sqlite:sarjan.db", env)
+		}
+		return ""
+	}
+	if kind == "database" && !strings.Contains(raw, "://") {
+		if !strings.Contains(raw, "=") {
+			return fmt.Sprintf("%s is neither a postgres:// URL, a key=value connection string nor sqlite:<path>", env)
+		}
+		return ""
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		// The parse error quotes the URL, which may hold a secret.
+		return fmt.Sprintf("%s is not a valid URL", env)
+	}
+	schemes := []string{"http", "https"}
+	if kind == "database" {
+		schemes = []string{"postgres", "postgresql"}
+	}
+	if !contains(schemes, u.Scheme) || u.Host == "" {
+		return fmt.Sprintf("%s must be a %s URL with a host", env, strings.Join(schemes, " or "))
+	}
+	return ""
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// Setting is one configuration value as it is safe to print.
+type Setting struct {
+	Name   string
+	Value  string
+	Source string
+}
+
+// Settings lists every setting in declaration order with secrets masked.
+func (l Loaded) Settings() []Setting {
+	var out []Setting
+	v := reflect.ValueOf(l.Config)
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		env := field.Tag.Get("env")
+		if env == "" {
+			continue
+		}
+		source := l.Sources[env]
+		if source == "" {
+			source = "unset"
+		}
+		out = append(out, Setting{Name: env, Value: redact(formatValue(v.Field(i)), field.Tag.Get("secret")), Source: source})
+	}
+	return out
+}
+
+// Report writes the redacted settings and every problem found.
+func (l Loaded) Report(w io.Writer) {
+	if l.File != "" {
+		fmt.Fprintf(w, "Config file: %s\n\n", l.File)
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, s := range l.Settings() {
+		fmt.Fprintf(tw, "%s\t%s\t(%s)\n", s.Name, s.Value, s.Source)
+	}
+	tw.Flush()
+
+	if len(l.Problems) == 0 {
+		fmt.Fprintln(w, "\nConfiguration OK")
+		return
+	}
+	fmt.Fprintf(w, "\n%d problem(s):\n", len(l.Problems))
+	for _, p := range l.Problems {
+		fmt.Fprintf(w, "  - %s\n", p)
+	}
+}
+
+func formatValue(v reflect.Value) string {
+	switch {
+	case v.Type() == durationType:
+		return time.Duration(v.Int()).String()
+	case v.Kind() == reflect.Slice:
+		return strings.Join(v.Interface().([]string), ",")
+	case v.Kind() == reflect.Map:
+		m := v.Interface().(map[string]string)
+		keys := make([]string, 0, len(m))
+		for k := range m {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		parts := make([]string, len(keys))
+		for i, k := range keys {
+			parts[i] = k + "=" + m[k]
+		}
+		return strings.Join(parts, ",")
+	default:
+		return fmt.Sprint(v.Interface())
+	}
+}
+
+var dsnPassword = regexp.MustCompile(`(?i)(password\s*=\s*)('[^']*'|\S+)`)
+
+// redact masks a value according to its secret tag.
+func redact(value, secret string) string {
+	if value == "" {
+		return value
+	}
+	switch secret {
+	case "true":
+		return "********"
+	case "url":
+		if !strings.Contains(value, "://") {
+			return dsnPassword.ReplaceAllString(value, "${1}********")
+		}
+		u, err := url.Parse(value)
+		if err != nil {
+			return "********"
+		}
+		// Webhook URLs keep their token in the last path segment.
+		if segments := strings.Split(strings.Trim(u.Path, "/"), "/"); len(segments) > 1 {
+			segments[len(segments)-1] = "redacted"
+			u.Path = "/" + strings.Join(segments, "/")
+			u.User = nil
+		}
+		u.RawQuery = ""
+		return u.Redacted()
+	}
+	return value
+}
@@ -1,22 +1,289 @@
 package config
 
 import (
+	"errors"
 	"fmt"
-
 	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/iamlucif3r/sarjan/internal/types"
 	"github.com/joho/godotenv"
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultFiles are tried, in order, when no config file is named.
+var DefaultFiles = []string{"sarjan.yaml", "sarjan.yml", "sarjan.toml"}
+
+// Where a setting came from, lowest precedence first.
+const (
+	SourceDefault = "default"
+	SourceFile    = "file"
+	SourceDotEnv  = ".env"
+	SourceEnv     = "env"
 )
 
-func SetConfig(Config *types.Config) error {
-	err := godotenv.Load(".env")
+// Loaded is a configuration along with where each setting came from and
+// everything wrong with it.
+type Loaded struct {
+	Config types.Config
+	// File is the config file that was read, if any.
+	File string
+	// Sources maps each env name to the Source* it was taken from; unset
+	// settings are absent.
+	Sources  map[string]string
+	Problems Problems
+}
+
+// Problems collects every configuration error so they can be fixed in
+// one go.
+type Problems []string
+
+func (p Problems) Error() string {
+	if len(p) == 1 {
+		return "invalid configuration: " + p[0]
+	}
+	return fmt.Sprintf("invalid configuration (%d problems):\n  - %s", len(p), strings.Join(p, "\n  - "))
+}
+
+// Load reads the configuration and returns it only if it is valid. file
+// names a YAML or TOML config file; when empty, CONFIG_FILE and then
+// DefaultFiles are tried and a missing file is fine.
+func Load(file string) (types.Config, error) {
+	loaded := Inspect(file)
+	if len(loaded.Problems) > 0 {
+		return loaded.Config, loaded.Problems
+	}
+	return loaded.Config, nil
+}
+
+// Inspect reads the configuration from defaults, the config file, .env
+// and the environment, later sources winning, and validates it. It never
+// stops at the first problem. A variable set to the empty string clears
+// what the config file or .env said, leaving the default. The process
+// environment is only read, never changed.
+func Inspect(file string) Loaded {
+	loaded := Loaded{Sources: map[string]string{}}
+
+	dotenv, err := godotenv.Read(".env")
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		loaded.Problems = append(loaded.Problems, fmt.Sprintf("reading .env: %v", err))
+	}
+	environ := make(map[string]string, len(dotenv))
+	for key, value := range dotenv {
+		environ[key] = value
+	}
+	for _, pair := range os.Environ() {
+		if key, value, ok := strings.Cut(pair, "="); ok {
+			environ[key] = value
+		}
+	}
+
+	fileValues, path, err := readConfigFile(file, environ["CONFIG_FILE"])
+	loaded.File = path
 	if err != nil {
-		return fmt.Errorf("error loading [.env] file: %v", err)
+		loaded.Problems = append(loaded.Problems, err.Error())
+	}
+
+	known := map[string]bool{}
+	v := reflect.ValueOf(&loaded.Config).Elem()
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		env := field.Tag.Get("env")
+		if env == "" {
+			continue
+		}
+		key := strings.ToLower(env)
+		known[key] = true
+
+		raw, source, set := field.Tag.Get("default"), SourceDefault, field.Tag.Get("default") != ""
+		if value, ok := fileValues[key]; ok {
+			raw, source, set = value, SourceFile, true
+		}
+		if value, ok := environ[env]; ok {
+			raw, source, set = value, SourceEnv, true
+			if value == "" {
+				raw = field.Tag.Get("default")
+			}
+			if _, real := os.LookupEnv(env); !real {
+				source = SourceDotEnv
+			}
+		}
+		if !set {
+			continue
+		}
+		loaded.Sources[env] = source
+		if raw == "" {
+			continue
+		}
+		if err := setField(v.Field(i), raw); err != nil {
+			loaded.Problems = append(loaded.Problems, fmt.Sprintf("%s (from %s): %v", env, source, err))
+			// Validate the default instead, so the one mistake is not
+			// reported again as an out-of-range zero.
+			if def := field.Tag.Get("default"); def != "" {
+				setField(v.Field(i), def)
+			}
+		}
+	}
+
+	var unknown []string
+	for key := range fileValues {
+		if !known[key] {
+			unknown = append(unknown, key)
+		}
+	}
+	sort.Strings(unknown)
+	for _, key := range unknown {
+		loaded.Problems = append(loaded.Problems, fmt.Sprintf("%s: unknown setting %q", path, key))
+	}
+
+	if loaded.Config.LLMBaseURL == "" && loaded.Config.OllamaURL != "" {
+		loaded.Config.LLMBaseURL = loaded.Config.OllamaURL
+		loaded.Sources["LLM_BASE_URL"] = loaded.Sources["OLLAMA_URL"]
+	}
+	loaded.Config.Env = environ
+	loaded.Problems = append(loaded.Problems, validate(loaded.Config)...)
+	return loaded
+}
+
+// readConfigFile returns the file's settings as strings keyed by lowercase
+// env name. Lists are joined with commas and maps written as name=value
+// pairs, the same as their env forms. fromEnv is CONFIG_FILE, used when
+// file is empty.
+func readConfigFile(file, fromEnv string) (map[string]string, string, error) {
+	explicit := file != ""
+	if file == "" {
+		file = fromEnv
+		explicit = file != ""
+	}
+	candidates := []string{file}
+	if !explicit {
+		candidates = DefaultFiles
+	}
+
+	for _, path := range candidates {
+		data, err := os.ReadFile(path)
+		if errors.Is(err, os.ErrNotExist) && !explicit {
+			continue
+		}
+		if err != nil {
+			return nil, path, fmt.Errorf("reading config file: %v", err)
+		}
+
+		raw := map[string]any{}
+		switch strings.ToLower(filepath.Ext(path)) {
+		case ".yaml", ".yml":
+			err = yaml.Unmarshal(data, &raw)
+		case ".toml":
+			err = toml.Unmarshal(data, &raw)
+		default:
+			return nil, path, fmt.Errorf("config file %s: want a .yaml, .yml or .toml file", path)
+		}
+		if err != nil {
+			return nil, path, fmt.Errorf("parsing config file %s: %v", path, err)
+		}
+
+		values := make(map[string]string, len(raw))
+		for key, value := range raw {
+			values[strings.ToLower(key)] = fileValueString(value)
+		}
+		return values, path, nil
+	}
+	return nil, "", nil
+}
+
+func fileValueString(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case []any:
+		parts := make([]string, len(v))
+		for i, item := range v {
+			parts[i] = fmt.Sprint(item)
+		}
+		return strings.Join(parts, ",")
+	case map[string]any:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		parts := make([]string, len(keys))
+		for i, key := range keys {
+			parts[i] = key + "=" + fmt.Sprint(v[key])
+		}
+		return strings.Join(parts, ",")
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// setField parses raw into a Config field by its type.
+func setField(field reflect.Value, raw string) error {
+	raw = strings.TrimSpace(raw)
+	switch {
+	case field.Type() == durationType:
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return fmt.Errorf("invalid duration %q (want e.g. 30s or 5m)", raw)
+		}
+		field.SetInt(int64(d))
+	case field.Kind() == reflect.String:
+		field.SetString(raw)
+	case field.Kind() == reflect.Int || field.Kind() == reflect.Int64:
+		n, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid number %q", raw)
+		}
+		field.SetInt(n)
+	case field.Kind() == reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return fmt.Errorf("invalid boolean %q (want true or false)", raw)
+		}
+		field.SetBool(b)
+	case field.Kind() == reflect.Slice:
+		field.Set(reflect.ValueOf(splitList(raw)))
+	case field.Kind() == reflect.Map:
+		m, err := parseMap(raw)
+		if err != nil {
+			return err
+		}
+		field.Set(reflect.ValueOf(m))
+	default:
+		return fmt.Errorf("unsupported setting type %s", field.Type())
 	}
-	Config.DatabaseURL = os.Getenv("DATABASE_URL")
-	Config.OllamaURL = os.Getenv("OLLAMA_URL")
-	Config.DiscordWebhookURL = os.Getenv("DISCORD_WEBHOOK_URL")
-	Config.OllamaModel = os.Getenv("LLM_MODEL")
 	return nil
 }
+
+// splitList splits a comma-separated value, dropping empty entries.
+func splitList(raw string) []string {
+	var out []string
+	for _, v := range strings.Split(raw, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+// parseMap parses "key=value,key=value" pairs.
+func parseMap(raw string) (map[string]string, error) {
+	out := map[string]string{}
+	for _, pair := range splitList(raw) {
+		k, v, ok := strings.Cut(pair, "=")
+		if !ok || strings.TrimSpace(k) == "" || strings.TrimSpace(v) == "" {
+			return nil, fmt.Errorf("invalid entry %q: want name=value", pair)
+		}
+		out[strings.TrimSpace(k)] = strings.TrimSpace(v)
+	}
+	return out, nil
+}
@@ -0,0 +1,165 @@
+package database
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"github.com/iamlucif3r/sarjan/internal/types"
+)
+
+func (s *store) SaveBundle(bundle types.Bundle) (int64, error) {
+	if bundle.Content == nil {
+		return 0, fmt.Errorf("bundle has no content")
+	}
+	encoded, err := json.Marshal(bundle.Content)
+	if err != nil {
+		return 0, fmt.Errorf("error encoding bundle: %v", err)
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(encoded, &fields); err != nil {
+		return 0, fmt.Errorf("error encoding bundle: %v", err)
+	}
+
+	if bundle.ArticleIDs == nil {
+		bundle.ArticleIDs = []int64{}
+	}
+	bundleErrors, err := json.Marshal(bundle.Errors)
+	if err != nil || bundle.Errors == nil {
+		bundleErrors = []byte("{}")
+	}
+	flags, err := json.Marshal(bundle.Flags)
+	if err != nil || bundle.Flags == nil {
+		flags = []byte("[]")
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("error starting transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	var id int64
+	err = tx.QueryRow(`INSERT INTO content_bundles (job_id, brand, model, prompt_version, article_ids, errors, flags)
+		VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING id`,
+		bundle.JobID, bundle.Brand, bundle.Model, bundle.PromptVersion, s.dialect.int64s(bundle.ArticleIDs), string(bundleErrors), string(flags)).Scan(&id)
+	if err != nil {
+		return 0, fmt.Errorf("error inserting bundle: %v", err)
+	}
+
+	for kind, raw := range fields {
+		// Items are stored one row each, keyed by their ContentIdeas field.
+		platform := types.PlatformOf(kind)
+		if platform == "" {
+			continue
+		}
+		var items []json.RawMessage
+		if err := json.Unmarshal(raw, &items); err != nil {
+			return 0, fmt.Errorf("error decoding %s: %v", kind, err)
+		}
+		for i, item := range items {
+			_, err := tx.Exec(`INSERT INTO content_items (bundle_id, platform, kind, position, body)
+				VALUES ($1, $2, $3, $4, $5)`, id, platform, kind, i, string(item))
+			if err != nil {
+				return 0, fmt.Errorf("error inserting %s item: %v", kind, err)
+			}
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("error committing bundle: %v", err)
+	}
+	return id, nil
+}
+
+// bundleQuery selects bundle metadata with a count of items per kind.
+func (s *store) bundleQuery() string {
+	return `SELECT b.id, b.job_id, b.brand, b.model, b.prompt_version, b.article_ids, b.errors, b.flags, b.created_at,
+		COALESCE((SELECT ` + s.dialect.objectAgg("kind", "n") + ` FROM
+			(SELECT kind, COUNT(*) AS n FROM content_items WHERE bundle_id = b.id GROUP BY kind) c), '{}')
+	FROM content_bundles b`
+}
+
+func (s *store) scanBundle(row interface{ Scan(...any) error }) (types.Bundle, error) {
+	var bundle types.Bundle
+	var jobID sql.NullInt64
+	var bundleErrors, flags, counts []byte
+	err := row.Scan(&bundle.ID, &jobID, &bundle.Brand, &bundle.Model, &bundle.PromptVersion,
+		s.dialect.int64sDest(&bundle.ArticleIDs), &bundleErrors, &flags, &bundle.CreatedAt, &counts)
+	if err != nil {
+		return bundle, err
+	}
+	if jobID.Valid {
+		bundle.JobID = &jobID.Int64
+	}
+	if err := json.Unmarshal(bundleErrors, &bundle.Errors); err != nil {
+		return bundle, fmt.Errorf("error decoding bundle errors: %v", err)
+	}
+	if err := json.Unmarshal(flags, &bundle.Flags); err != nil {
+		return bundle, fmt.Errorf("error decoding bundle flags: %v", err)
+	}
+	if err := json.Unmarshal(counts, &bundle.ItemCounts); err != nil {
+		return bundle, fmt.Errorf("error decoding item counts: %v", err)
+	}
+	return bundle, nil
+}
+
+// ListBundles returns bundle metadata, newest first, without item bodies.
+func (s *store) ListBundles(limit, offset int) ([]types.Bundle, error) {
+	rows, err := s.db.Query(s.bundleQuery()+` ORDER BY b.id DESC LIMIT $1 OFFSET $2`, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("error listing bundles: %v", err)
+	}
+	defer rows.Close()
+
+	bundles := []types.Bundle{}
+	for rows.Next() {
+		bundle, err := s.scanBundle(rows)
+		if err != nil {
+			return nil, err
+		}
+		bundles = append(bundles, bundle)
+	}
+	return bundles, rows.Err()
+}
+
+// GetBundle returns a bundle with its content rebuilt from the item rows.
+func (s *store) GetBundle(id int64) (types.Bundle, error) {
+	bundle, err := s.scanBundle(s.db.QueryRow(s.bundleQuery()+` WHERE b.id = $1`, id))
+	if err == sql.ErrNoRows {
+		return bundle, err
+	}
+	if err != nil {
+		return bundle, fmt.Errorf("error fetching bundle %d: %v", id, err)
+	}
+
+	rows, err := s.db.Query(`SELECT kind, body FROM content_items WHERE bundle_id = $1 ORDER BY kind, position`, id)
+	if err != nil {
+		return bundle, fmt.Errorf("error fetching bundle %d items: %v", id, err)
+	}
+	defer rows.Close()
+
+	fields := map[string][]json.RawMessage{}
+	for rows.Next() {
+		var kind string
+		var body []byte
+		if err := rows.Scan(&kind, &body); err != nil {
+			return bundle, err
+		}
+		fields[kind] = append(fields[kind], json.RawMessage(body))
+	}
+	if err := rows.Err(); err != nil {
+		return bundle, err
+	}
+
+	encoded, err := json.Marshal(fields)
+	if err != nil {
+		return bundle, fmt.Errorf("error rebuilding bundle %d: %v", id, err)
+	}
+	var content types.ContentIdeas
+	if err := json.Unmarshal(encoded, &content); err != nil {
+		return bundle, fmt.Errorf("error rebuilding bundle %d: %v", id, err)
+	}
+	bundle.Content = &content
+	return bundle, nil
+}
@@ -1,31 +1,68 @@
 package database
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"log"
+	"strings"
 
 	_ "github.com/lib/pq"
 
 	"github.com/iamlucif3r/sarjan/internal/types"
 )
 
-var DB *sql.DB
-
-func ConnectDB(cfg types.Config) (*sql.DB, error) {
-	connStr := cfg.DatabaseURL
-	var err error
-	DB, err = sql.Open("postgres", connStr)
+// ConnectDB opens the Repository DATABASE_URL names, a postgres:// URL or
+// key=value string, or sqlite:<path> for a local file, with the configured
+// pool limits, and pings it so an unreachable database is reported at
+// startup. Callers own the returned handle and pass it on; there is no
+// package-level connection.
+func ConnectDB(cfg types.Config) (Repository, error) {
+	driver, dsn := "postgres", cfg.DatabaseURL
+	path, isSQLite := SQLitePath(cfg.DatabaseURL)
+	if isSQLite {
+		driver, dsn = "sqlite", sqliteDSN(path)
+	}
+	db, err := sql.Open(driver, dsn)
 	if err != nil {
 		return nil, fmt.Errorf("error opening database: %v", err)
 	}
 
-	// err = DB.Ping()
-	// if err != nil {
-	// 	return nil, fmt.Errorf("error pinging database: %v", err)
-	// }
+	db.SetMaxOpenConns(cfg.DBMaxOpenConns)
+	db.SetMaxIdleConns(cfg.DBMaxIdleConns)
+	db.SetConnMaxLifetime(cfg.DBConnMaxLifetime)
+	db.SetConnMaxIdleTime(cfg.DBConnMaxIdleTime)
+	if path == ":memory:" {
+		// Every connection to :memory: is a new, empty database, so keep
+		// exactly one open for good.
+		db.SetMaxOpenConns(1)
+		db.SetMaxIdleConns(1)
+		db.SetConnMaxLifetime(0)
+		db.SetConnMaxIdleTime(0)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.DBPingTimeout)
+	defer cancel()
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("error pinging database: %v", err)
+	}
 
-	log.Println("Successfully connected to the database")
+	log.Printf("Successfully connected to the %s database", driver)
 
-	return DB, nil
+	if isSQLite {
+		return NewSQLite(db), nil
+	}
+	return NewPostgres(db), nil
+}
+
+// SQLitePath returns the file path of a sqlite: DATABASE_URL. Both
+// sqlite:dev.db and sqlite://dev.db name ./dev.db, and sqlite:///tmp/x.db
+// an absolute path.
+func SQLitePath(url string) (string, bool) {
+	rest, ok := strings.CutPrefix(url, "sqlite:")
+	if !ok {
+		return "", false
+	}
+	return strings.TrimPrefix(rest, "//"), true
 }
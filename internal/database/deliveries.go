@@ -0,0 +1,43 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/iamlucif3r/sarjan/internal/types"
+)
+
+// SaveDeliveryAttempt records one webhook request made for a job.
+func (s *store) SaveDeliveryAttempt(jobID *int64, a types.DeliveryAttempt) error {
+	var status sql.NullInt64
+	if a.StatusCode != 0 {
+		status = sql.NullInt64{Int64: int64(a.StatusCode), Valid: true}
+	}
+	_, err := s.db.Exec(`INSERT INTO delivery_attempts (job_id, sink, target, attempt, status_code, message_id, error, retry_after_ms, duration_ms, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`,
+		jobID, a.Sink, a.Target, a.Attempt, status, a.MessageID, a.Error, a.RetryAfterMS, a.DurationMS, a.At)
+	if err != nil {
+		return fmt.Errorf("error saving delivery attempt: %v", err)
+	}
+	return nil
+}
+
+// ListDeliveryAttempts returns a job's webhook requests, oldest first.
+func (s *store) ListDeliveryAttempts(jobID int64) ([]types.DeliveryAttempt, error) {
+	rows, err := s.db.Query(`SELECT sink, target, attempt, COALESCE(status_code, 0), message_id, error, retry_after_ms, duration_ms, created_at
+		FROM delivery_attempts WHERE job_id = $1 ORDER BY created_at, id`, jobID)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching delivery attempts for job %d: %v", jobID, err)
+	}
+	defer rows.Close()
+
+	var out []types.DeliveryAttempt
+	for rows.Next() {
+		var a types.DeliveryAttempt
+		if err := rows.Scan(&a.Sink, &a.Target, &a.Attempt, &a.StatusCode, &a.MessageID, &a.Error, &a.RetryAfterMS, &a.DurationMS, &a.At); err != nil {
+			return nil, fmt.Errorf("error scanning delivery attempt: %v", err)
+		}
+		out = append(out, a)
+	}
+	return out, rows.Err()
+}
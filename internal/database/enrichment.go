@@ -0,0 +1,45 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/iamlucif3r/sarjan/internal/types"
+)
+
+// GetCachedContent returns previously extracted content for url, or
+// sql.ErrNoRows when the page has not been fetched before.
+func (s *store) GetCachedContent(url string) (types.ExtractedArticle, error) {
+	extracted := types.ExtractedArticle{URL: url}
+	var publishedAt sql.NullTime
+	err := s.db.QueryRow(`SELECT title, author, published_at, canonical_url, content
+		FROM enrichment_cache WHERE url = $1`, url).
+		Scan(&extracted.Title, &extracted.Author, &publishedAt, &extracted.CanonicalURL, &extracted.Text)
+	if err == sql.ErrNoRows {
+		return extracted, err
+	}
+	if err != nil {
+		return extracted, fmt.Errorf("error reading enrichment cache: %v", err)
+	}
+	if publishedAt.Valid {
+		extracted.PublishedAt = &publishedAt.Time
+	}
+	return extracted, nil
+}
+
+func (s *store) SaveCachedContent(extracted types.ExtractedArticle) error {
+	_, err := s.db.Exec(`INSERT INTO enrichment_cache (url, title, author, published_at, canonical_url, content)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (url) DO UPDATE SET
+			title = EXCLUDED.title,
+			author = EXCLUDED.author,
+			published_at = EXCLUDED.published_at,
+			canonical_url = EXCLUDED.canonical_url,
+			content = EXCLUDED.content,
+			fetched_at = CURRENT_TIMESTAMP`,
+		extracted.URL, extracted.Title, extracted.Author, extracted.PublishedAt, extracted.CanonicalURL, extracted.Text)
+	if err != nil {
+		return fmt.Errorf("error writing enrichment cache: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+package database
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+)
+
+//go:embed fixtures/articles.json
+var fixtureFiles embed.FS
+
+// ArticleFixture is one article for seeding a local database. Age, e.g.
+// "6h", sets PublishedAt relative to when the fixtures are loaded, so time
+// window filters keep matching freshly seeded data.
+type ArticleFixture struct {
+	Title       string     `json:"title"`
+	Description string     `json:"description"`
+	Link        string     `json:"link"`
+	LLMScore    *float64   `json:"llm_score"`
+	Source      string     `json:"source"`
+	PublishedAt *time.Time `json:"published_at,omitempty"`
+	Age         string     `json:"age,omitempty"`
+}
+
+// DefaultFixtures returns the embedded sample articles.
+func DefaultFixtures() ([]ArticleFixture, error) {
+	data, err := fixtureFiles.ReadFile("fixtures/articles.json")
+	if err != nil {
+		return nil, err
+	}
+	return parseFixtures("fixtures/articles.json", data)
+}
+
+// LoadFixtures reads articles from a JSON file in the same format as the
+// embedded fixtures.
+func LoadFixtures(path string) ([]ArticleFixture, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading fixtures: %v", err)
+	}
+	return parseFixtures(path, data)
+}
+
+func parseFixtures(name string, data []byte) ([]ArticleFixture, error) {
+	var fixtures []ArticleFixture
+	if err := json.Unmarshal(data, &fixtures); err != nil {
+		return nil, fmt.Errorf("error parsing %s: %v", name, err)
+	}
+	now := time.Now()
+	for i, f := range fixtures {
+		if f.Title == "" || f.Link == "" {
+			return nil, fmt.Errorf("%s: article %d needs a title and a link", name, i+1)
+		}
+		if f.Age != "" {
+			age, err := time.ParseDuration(f.Age)
+			if err != nil {
+				return nil, fmt.Errorf("%s: article %d: invalid age %q", name, i+1, f.Age)
+			}
+			published := now.Add(-age)
+			fixtures[i].PublishedAt = &published
+		}
+	}
+	return fixtures, nil
+}
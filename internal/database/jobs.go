@@ -0,0 +1,105 @@
+package database
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/iamlucif3r/sarjan/internal/types"
+)
+
+const jobColumns = `id, state, request, errors, artifacts, created_at, updated_at, started_at, finished_at`
+
+func scanJob(row interface{ Scan(...any) error }) (types.Job, error) {
+	var job types.Job
+	var request, errs, artifacts []byte
+	var startedAt, finishedAt sql.NullTime
+	err := row.Scan(&job.ID, &job.State, &request, &errs, &artifacts, &job.CreatedAt, &job.UpdatedAt, &startedAt, &finishedAt)
+	if err != nil {
+		return job, err
+	}
+	if err := json.Unmarshal(request, &job.Request); err != nil {
+		return job, fmt.Errorf("error decoding job request: %v", err)
+	}
+	if err := json.Unmarshal(errs, &job.Errors); err != nil {
+		return job, fmt.Errorf("error decoding job errors: %v", err)
+	}
+	if err := json.Unmarshal(artifacts, &job.Artifacts); err != nil {
+		return job, fmt.Errorf("error decoding job artifacts: %v", err)
+	}
+	if startedAt.Valid {
+		job.StartedAt = &startedAt.Time
+	}
+	if finishedAt.Valid {
+		job.FinishedAt = &finishedAt.Time
+	}
+	return job, nil
+}
+
+func (s *store) CreateJob(req types.GenerateRequest) (types.Job, error) {
+	encoded, err := json.Marshal(req)
+	if err != nil {
+		return types.Job{}, fmt.Errorf("error encoding job request: %v", err)
+	}
+	row := s.db.QueryRow(`INSERT INTO jobs (state, request) VALUES ($1, $2) RETURNING `+jobColumns, types.JobQueued, string(encoded))
+	job, err := scanJob(row)
+	if err != nil {
+		return job, fmt.Errorf("error creating job: %v", err)
+	}
+	return job, nil
+}
+
+func (s *store) GetJob(id int64) (types.Job, error) {
+	job, err := scanJob(s.db.QueryRow(`SELECT `+jobColumns+` FROM jobs WHERE id = $1`, id))
+	if err == sql.ErrNoRows {
+		return job, err
+	}
+	if err != nil {
+		return job, fmt.Errorf("error fetching job %d: %v", id, err)
+	}
+	return job, nil
+}
+
+// ListJobsInState returns jobs in any of the given states, oldest first.
+func (s *store) ListJobsInState(states ...types.JobState) ([]types.Job, error) {
+	if len(states) == 0 {
+		return nil, nil
+	}
+	placeholders := make([]string, len(states))
+	args := make([]any, len(states))
+	for i, state := range states {
+		placeholders[i] = fmt.Sprintf("$%d", i+1)
+		args[i] = string(state)
+	}
+	rows, err := s.db.Query(`SELECT `+jobColumns+` FROM jobs WHERE state IN (`+strings.Join(placeholders, ", ")+`) ORDER BY id`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error listing jobs: %v", err)
+	}
+	defer rows.Close()
+
+	var jobs []types.Job
+	for rows.Next() {
+		job, err := scanJob(rows)
+		if err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, job)
+	}
+	return jobs, rows.Err()
+}
+
+// SetJobState moves a job to a new state, stamping started_at on the first
+// transition out of queued and finished_at on done/failed.
+func (s *store) SetJobState(id int64, state types.JobState) error {
+	_, err := s.db.Exec(`UPDATE jobs SET
+		state = $2,
+		updated_at = CURRENT_TIMESTAMP,
+		started_at = CASE WHEN started_at IS NULL AND $2 <> 'queued' THEN CURRENT_TIMESTAMP ELSE started_at END,
+		finished_at = CASE WHEN $2 IN ('done', 'failed') THEN CURRENT_TIMESTAMP ELSE finished_at END
+		WHERE id = $1`, id, string(state))
+	if err != nil {
+		return fmt.Errorf("error updating job %d state: %v", id, err)
+	}
+	return nil
+}
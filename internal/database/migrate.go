@@ -0,0 +1,236 @@
+package database
+
+import (
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//go:embed migrations
+var migrationFiles embed.FS
+
+// Migration is one embedded schema change, read from
+// migrations/<driver>/<version>_<name>.up.sql and its .down.sql.
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+// MigrationState is a migration and when it was applied, if it was.
+type MigrationState struct {
+	Version   int        `json:"version"`
+	Name      string     `json:"name"`
+	AppliedAt *time.Time `json:"applied_at,omitempty"`
+	// Unknown marks a version recorded in the database that this binary
+	// does not ship, e.g. after a downgrade.
+	Unknown bool `json:"unknown,omitempty"`
+}
+
+// Migrations returns a driver's embedded migrations in version order.
+func Migrations(driver string) ([]Migration, error) {
+	dir := "migrations/" + driver
+	entries, err := fs.ReadDir(migrationFiles, dir)
+	if err != nil {
+		return nil, err
+	}
+	byVersion := map[int]*Migration{}
+	for _, entry := range entries {
+		file := entry.Name()
+		base, direction, ok := strings.Cut(strings.TrimSuffix(file, ".sql"), ".")
+		if !ok || (direction != "up" && direction != "down") {
+			return nil, fmt.Errorf("migration %s: want <version>_<name>.up.sql or .down.sql", file)
+		}
+		prefix, name, _ := strings.Cut(base, "_")
+		version, err := strconv.Atoi(prefix)
+		if err != nil || version < 1 {
+			return nil, fmt.Errorf("migration %s: version must be a positive number", file)
+		}
+		body, err := migrationFiles.ReadFile(dir + "/" + file)
+		if err != nil {
+			return nil, err
+		}
+		m := byVersion[version]
+		if m == nil {
+			m = &Migration{Version: version, Name: name}
+			byVersion[version] = m
+		} else if m.Name != name {
+			return nil, fmt.Errorf("migration %d has two names: %s and %s", version, m.Name, name)
+		}
+		if direction == "up" {
+			m.Up = string(body)
+		} else {
+			m.Down = string(body)
+		}
+	}
+
+	out := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" {
+			return nil, fmt.Errorf("migration %d_%s has no .up.sql", m.Version, m.Name)
+		}
+		out = append(out, *m)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Version < out[j].Version })
+	return out, nil
+}
+
+// MigrationStatus lists every embedded migration and any applied version
+// the binary does not know, in version order.
+func (s *store) MigrationStatus() ([]MigrationState, error) {
+	migrations, err := Migrations(s.dialect.name)
+	if err != nil {
+		return nil, err
+	}
+	applied, err := s.appliedMigrations()
+	if err != nil {
+		return nil, err
+	}
+	var out []MigrationState
+	for _, m := range migrations {
+		state := MigrationState{Version: m.Version, Name: m.Name}
+		if a, ok := applied[m.Version]; ok {
+			state.AppliedAt = a.AppliedAt
+			delete(applied, m.Version)
+		}
+		out = append(out, state)
+	}
+	for _, a := range applied {
+		a.Unknown = true
+		out = append(out, a)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Version < out[j].Version })
+	return out, nil
+}
+
+// PendingMigrations returns the embedded migrations not yet applied.
+func (s *store) PendingMigrations() ([]Migration, error) {
+	migrations, err := Migrations(s.dialect.name)
+	if err != nil {
+		return nil, err
+	}
+	applied, err := s.appliedMigrations()
+	if err != nil {
+		return nil, err
+	}
+	var pending []Migration
+	for _, m := range migrations {
+		if _, ok := applied[m.Version]; !ok {
+			pending = append(pending, m)
+		}
+	}
+	return pending, nil
+}
+
+// MigrateUp applies every pending migration in order, each in its own
+// transaction, and returns the ones it applied. Concurrent callers are
+// serialised, so two instances starting together apply each migration
+// once.
+func (s *store) MigrateUp() ([]Migration, error) {
+	pending, err := s.PendingMigrations()
+	if err != nil {
+		return nil, err
+	}
+	var done []Migration
+	for _, m := range pending {
+		applied, err := s.inMigrationTx(func(tx *sql.Tx) (bool, error) {
+			var exists bool
+			if err := tx.QueryRow(`SELECT EXISTS (SELECT 1 FROM schema_migrations WHERE version = $1)`, m.Version).Scan(&exists); err != nil || exists {
+				return false, err
+			}
+			if _, err := tx.Exec(m.Up); err != nil {
+				return false, err
+			}
+			_, err := tx.Exec(`INSERT INTO schema_migrations (version, name) VALUES ($1, $2)`, m.Version, m.Name)
+			return err == nil, err
+		})
+		if err != nil {
+			return done, fmt.Errorf("migration %d_%s failed: %v", m.Version, m.Name, err)
+		}
+		if applied {
+			done = append(done, m)
+		}
+	}
+	return done, nil
+}
+
+// MigrateDown reverts the latest steps applied migrations, newest first,
+// and returns the ones it reverted.
+func (s *store) MigrateDown(steps int) ([]Migration, error) {
+	migrations, err := Migrations(s.dialect.name)
+	if err != nil {
+		return nil, err
+	}
+	applied, err := s.appliedMigrations()
+	if err != nil {
+		return nil, err
+	}
+	var done []Migration
+	for i := len(migrations) - 1; i >= 0 && len(done) < steps; i-- {
+		m := migrations[i]
+		if _, ok := applied[m.Version]; !ok {
+			continue
+		}
+		if m.Down == "" {
+			return done, fmt.Errorf("migration %d_%s has no .down.sql and cannot be reverted", m.Version, m.Name)
+		}
+		_, err := s.inMigrationTx(func(tx *sql.Tx) (bool, error) {
+			if _, err := tx.Exec(m.Down); err != nil {
+				return false, err
+			}
+			_, err := tx.Exec(`DELETE FROM schema_migrations WHERE version = $1`, m.Version)
+			return err == nil, err
+		})
+		if err != nil {
+			return done, fmt.Errorf("reverting migration %d_%s failed: %v", m.Version, m.Name, err)
+		}
+		done = append(done, m)
+	}
+	return done, nil
+}
+
+func (s *store) inMigrationTx(fn func(*sql.Tx) (bool, error)) (bool, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+	if s.dialect.lockMigrations != "" {
+		if _, err := tx.Exec(s.dialect.lockMigrations); err != nil {
+			return false, err
+		}
+	}
+	changed, err := fn(tx)
+	if err != nil {
+		return false, err
+	}
+	return changed, tx.Commit()
+}
+
+func (s *store) appliedMigrations() (map[int]MigrationState, error) {
+	if _, err := s.db.Exec(s.dialect.migrationsTable); err != nil {
+		return nil, fmt.Errorf("error creating schema_migrations: %v", err)
+	}
+	rows, err := s.db.Query(`SELECT version, name, applied_at FROM schema_migrations`)
+	if err != nil {
+		return nil, fmt.Errorf("error reading schema_migrations: %v", err)
+	}
+	defer rows.Close()
+	applied := map[int]MigrationState{}
+	for rows.Next() {
+		var state MigrationState
+		var at time.Time
+		if err := rows.Scan(&state.Version, &state.Name, &at); err != nil {
+			return nil, err
+		}
+		state.AppliedAt = &at
+		applied[state.Version] = state
+	}
+	return applied, rows.Err()
+}
@@ -0,0 +1,91 @@
+-- SARJAN's own tables. The upstream articles table belongs to TRIKAL and
+-- is only checked, never created. Statements are idempotent so databases
+-- set up before migrations existed are adopted as they are.
+
+CREATE TABLE IF NOT EXISTS jobs (
+    id          BIGSERIAL PRIMARY KEY,
+    state       TEXT NOT NULL DEFAULT 'queued',
+    errors      JSONB NOT NULL DEFAULT '{}',
+    artifacts   JSONB NOT NULL DEFAULT '[]',
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    started_at  TIMESTAMPTZ,
+    finished_at TIMESTAMPTZ
+);
+
+ALTER TABLE jobs ADD COLUMN IF NOT EXISTS request JSONB NOT NULL DEFAULT '{}';
+
+CREATE TABLE IF NOT EXISTS content_bundles (
+    id             BIGSERIAL PRIMARY KEY,
+    job_id         BIGINT REFERENCES jobs(id) ON DELETE SET NULL,
+    model          TEXT NOT NULL,
+    prompt_version TEXT NOT NULL,
+    article_ids    BIGINT[] NOT NULL DEFAULT '{}',
+    created_at     TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+ALTER TABLE content_bundles ADD COLUMN IF NOT EXISTS brand TEXT NOT NULL DEFAULT 'pwnspectrum';
+
+ALTER TABLE content_bundles ADD COLUMN IF NOT EXISTS errors JSONB NOT NULL DEFAULT '{}';
+
+ALTER TABLE content_bundles ADD COLUMN IF NOT EXISTS flags JSONB NOT NULL DEFAULT '[]';
+
+CREATE TABLE IF NOT EXISTS content_items (
+    id        BIGSERIAL PRIMARY KEY,
+    bundle_id BIGINT NOT NULL REFERENCES content_bundles(id) ON DELETE CASCADE,
+    platform  TEXT NOT NULL,
+    kind      TEXT NOT NULL,
+    position  INT NOT NULL,
+    body      JSONB NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS content_items_bundle_idx ON content_items (bundle_id, kind, position);
+
+CREATE TABLE IF NOT EXISTS article_rankings (
+    id         BIGSERIAL PRIMARY KEY,
+    article_id BIGINT NOT NULL,
+    job_id     BIGINT REFERENCES jobs(id) ON DELETE SET NULL,
+    rank       INT NOT NULL,
+    round      INT NOT NULL,
+    score      DOUBLE PRECISION NOT NULL,
+    rationale  TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS article_rankings_article_idx ON article_rankings (article_id, created_at DESC);
+
+CREATE TABLE IF NOT EXISTS prompt_versions (
+    id         TEXT PRIMARY KEY,
+    name       TEXT NOT NULL,
+    version    TEXT NOT NULL,
+    hash       TEXT NOT NULL,
+    body       TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS enrichment_cache (
+    url           TEXT PRIMARY KEY,
+    title         TEXT NOT NULL DEFAULT '',
+    author        TEXT NOT NULL DEFAULT '',
+    published_at  TIMESTAMPTZ,
+    canonical_url TEXT NOT NULL DEFAULT '',
+    content       TEXT NOT NULL,
+    fetched_at    TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS delivery_attempts (
+    id          BIGSERIAL PRIMARY KEY,
+    job_id      BIGINT REFERENCES jobs(id) ON DELETE CASCADE,
+    target      TEXT NOT NULL,
+    attempt     INT NOT NULL,
+    status_code INT,
+    message_id  TEXT NOT NULL DEFAULT '',
+    error       TEXT NOT NULL DEFAULT '',
+    retry_after_ms BIGINT NOT NULL DEFAULT 0,
+    duration_ms    BIGINT NOT NULL,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+ALTER TABLE delivery_attempts ADD COLUMN IF NOT EXISTS sink TEXT NOT NULL DEFAULT '';
+
+CREATE INDEX IF NOT EXISTS delivery_attempts_job_idx ON delivery_attempts (job_id, created_at);
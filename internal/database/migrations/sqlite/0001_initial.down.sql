@@ -0,0 +1,8 @@
+DROP TABLE IF EXISTS delivery_attempts;
+DROP TABLE IF EXISTS enrichment_cache;
+DROP TABLE IF EXISTS prompt_versions;
+DROP TABLE IF EXISTS article_rankings;
+DROP TABLE IF EXISTS content_items;
+DROP TABLE IF EXISTS content_bundles;
+DROP TABLE IF EXISTS jobs;
+DROP TABLE IF EXISTS articles;
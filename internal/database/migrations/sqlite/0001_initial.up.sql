@@ -0,0 +1,97 @@
+-- SARJAN's tables for a local SQLite database. JSON columns are TEXT read
+-- with SQLite's json functions, and article_ids is a JSON array.
+
+-- There is no TRIKAL in local use, so the articles table it would own is
+-- created here and filled with `sarjan seed`.
+CREATE TABLE IF NOT EXISTS articles (
+    id           INTEGER PRIMARY KEY AUTOINCREMENT,
+    title        TEXT NOT NULL,
+    description  TEXT,
+    link         TEXT NOT NULL UNIQUE,
+    llm_score    REAL,
+    published_at TIMESTAMP,
+    source       TEXT
+);
+
+CREATE TABLE IF NOT EXISTS jobs (
+    id          INTEGER PRIMARY KEY AUTOINCREMENT,
+    state       TEXT NOT NULL DEFAULT 'queued',
+    request     TEXT NOT NULL DEFAULT '{}',
+    errors      TEXT NOT NULL DEFAULT '{}',
+    artifacts   TEXT NOT NULL DEFAULT '[]',
+    created_at  TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at  TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    started_at  TIMESTAMP,
+    finished_at TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS content_bundles (
+    id             INTEGER PRIMARY KEY AUTOINCREMENT,
+    job_id         INTEGER REFERENCES jobs(id) ON DELETE SET NULL,
+    brand          TEXT NOT NULL DEFAULT 'pwnspectrum',
+    model          TEXT NOT NULL,
+    prompt_version TEXT NOT NULL,
+    article_ids    TEXT NOT NULL DEFAULT '[]',
+    errors         TEXT NOT NULL DEFAULT '{}',
+    flags          TEXT NOT NULL DEFAULT '[]',
+    created_at     TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS content_items (
+    id        INTEGER PRIMARY KEY AUTOINCREMENT,
+    bundle_id INTEGER NOT NULL REFERENCES content_bundles(id) ON DELETE CASCADE,
+    platform  TEXT NOT NULL,
+    kind      TEXT NOT NULL,
+    position  INTEGER NOT NULL,
+    body      TEXT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS content_items_bundle_idx ON content_items (bundle_id, kind, position);
+
+CREATE TABLE IF NOT EXISTS article_rankings (
+    id         INTEGER PRIMARY KEY AUTOINCREMENT,
+    article_id INTEGER NOT NULL,
+    job_id     INTEGER REFERENCES jobs(id) ON DELETE SET NULL,
+    rank       INTEGER NOT NULL,
+    round      INTEGER NOT NULL,
+    score      REAL NOT NULL,
+    rationale  TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS article_rankings_article_idx ON article_rankings (article_id, created_at DESC);
+
+CREATE TABLE IF NOT EXISTS prompt_versions (
+    id         TEXT PRIMARY KEY,
+    name       TEXT NOT NULL,
+    version    TEXT NOT NULL,
+    hash       TEXT NOT NULL,
+    body       TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS enrichment_cache (
+    url           TEXT PRIMARY KEY,
+    title         TEXT NOT NULL DEFAULT '',
+    author        TEXT NOT NULL DEFAULT '',
+    published_at  TIMESTAMP,
+    canonical_url TEXT NOT NULL DEFAULT '',
+    content       TEXT NOT NULL,
+    fetched_at    TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS delivery_attempts (
+    id             INTEGER PRIMARY KEY AUTOINCREMENT,
+    job_id         INTEGER REFERENCES jobs(id) ON DELETE CASCADE,
+    sink           TEXT NOT NULL DEFAULT '',
+    target         TEXT NOT NULL,
+    attempt        INTEGER NOT NULL,
+    status_code    INTEGER,
+    message_id     TEXT NOT NULL DEFAULT '',
+    error          TEXT NOT NULL DEFAULT '',
+    retry_after_ms INTEGER NOT NULL DEFAULT 0,
+    duration_ms    INTEGER NOT NULL,
+    created_at     TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS delivery_attempts_job_idx ON delivery_attempts (job_id, created_at);
@@ -0,0 +1,149 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/lib/pq"
+
+	"github.com/iamlucif3r/sarjan/internal/types"
+)
+
+// Postgres is the production Repository. The articles table belongs to
+// TRIKAL and is only read.
+type Postgres struct {
+	store
+}
+
+func NewPostgres(db *sql.DB) *Postgres {
+	return &Postgres{store{db: db, dialect: dialect{
+		name:       "postgres",
+		int64s:     func(ids []int64) any { return pq.Array(ids) },
+		int64sDest: func(ids *[]int64) any { return pq.Array(ids) },
+		objectAgg:  func(key, value string) string { return "jsonb_object_agg(" + key + ", " + value + ")" },
+		migrationsTable: `CREATE TABLE IF NOT EXISTS schema_migrations (
+			version    INT PRIMARY KEY,
+			name       TEXT NOT NULL,
+			applied_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+		)`,
+		lockMigrations: `LOCK TABLE schema_migrations IN EXCLUSIVE MODE`,
+	}}}
+}
+
+// FetchTopRankedArticles returns up to q.Limit articles matching q, best
+// upstream llm_score first and newest first among ties. The time window
+// and source filters read TRIKAL's published_at and source columns, and are
+// only referenced when set.
+func (p *Postgres) FetchTopRankedArticles(q types.ArticleQuery) ([]types.JudgedArticle, error) {
+	if q.Limit < 1 {
+		return nil, fmt.Errorf("limit must be positive")
+	}
+
+	where := []string{"llm_score IS NOT NULL"}
+	var args []any
+	arg := func(v any) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	if q.MinScore != nil {
+		where = append(where, "llm_score >= "+arg(*q.MinScore))
+	}
+	if q.PublishedAfter != nil {
+		where = append(where, "published_at >= "+arg(*q.PublishedAfter))
+	}
+	if len(q.Sources) > 0 {
+		where = append(where, "source = ANY("+arg(pq.Array(q.Sources))+")")
+	}
+	if include := likePatterns(q.Include); len(include) > 0 {
+		p := arg(pq.Array(include))
+		where = append(where, "(title ILIKE ANY("+p+") OR description ILIKE ANY("+p+"))")
+	}
+	if exclude := likePatterns(q.Exclude); len(exclude) > 0 {
+		p := arg(pq.Array(exclude))
+		where = append(where, "NOT (title ILIKE ANY("+p+") OR COALESCE(description, '') ILIKE ANY("+p+"))")
+	}
+	if q.ExcludeUsed {
+		where = append(where, "NOT EXISTS (SELECT 1 FROM content_bundles b WHERE articles.id = ANY(b.article_ids))")
+	}
+
+	query := `SELECT id, title, COALESCE(description, ''), link FROM articles WHERE ` + strings.Join(where, " AND ") +
+		` ORDER BY llm_score DESC, id DESC LIMIT ` + arg(q.Limit)
+	rows, err := p.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query articles: %v", err)
+	}
+	defer rows.Close()
+
+	var articles []types.JudgedArticle
+	for rows.Next() {
+		var art types.JudgedArticle
+		if err := rows.Scan(&art.ID, &art.Title, &art.Content, &art.URL); err != nil {
+			return nil, err
+		}
+		articles = append(articles, art)
+	}
+	return articles, rows.Err()
+}
+
+func (p *Postgres) SetJobStageError(id int64, stage types.JobState, msg string) error {
+	_, err := p.db.Exec(`UPDATE jobs SET
+		errors = errors || jsonb_build_object($2::text, $3::text),
+		updated_at = NOW()
+		WHERE id = $1`, id, string(stage), msg)
+	if err != nil {
+		return fmt.Errorf("error recording job %d error: %v", id, err)
+	}
+	return nil
+}
+
+func (p *Postgres) AddJobArtifact(id int64, link string) error {
+	_, err := p.db.Exec(`UPDATE jobs SET
+		artifacts = artifacts || jsonb_build_array($2::text),
+		updated_at = NOW()
+		WHERE id = $1`, id, link)
+	if err != nil {
+		return fmt.Errorf("error recording job %d artifact: %v", id, err)
+	}
+	return nil
+}
+
+// CheckUpstream verifies that the articles table SARJAN reads, but does
+// not own, has the columns it needs. It returns the optional columns that
+// are missing so the caller can warn about the filters that will fail.
+func (p *Postgres) CheckUpstream() ([]string, error) {
+	wanted := append(append([]string{}, ArticleColumns...), OptionalArticleColumns...)
+	rows, err := p.db.Query(`SELECT column_name FROM information_schema.columns
+		WHERE table_name = 'articles' AND table_schema = ANY(current_schemas(false)) AND column_name = ANY($1)`, pq.Array(wanted))
+	if err != nil {
+		return nil, fmt.Errorf("error inspecting the articles table: %v", err)
+	}
+	defer rows.Close()
+	present := map[string]bool{}
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		present[name] = true
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return checkArticleColumns(present)
+}
+
+// likePatterns turns keywords into case-insensitive substring patterns,
+// escaping LIKE metacharacters in the keyword itself.
+func likePatterns(keywords []string) []string {
+	escape := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+	patterns := make([]string, 0, len(keywords))
+	for _, k := range keywords {
+		if k = strings.TrimSpace(k); k != "" {
+			patterns = append(patterns, "%"+escape.Replace(k)+"%")
+		}
+	}
+	return patterns
+}
@@ -0,0 +1,15 @@
+package database
+
+import "fmt"
+
+// SavePromptVersion records the exact text behind a prompt ID the first
+// time it is used, so bundles can be traced back to the prompt that made
+// them.
+func (s *store) SavePromptVersion(id, name, version, hash, body string) error {
+	_, err := s.db.Exec(`INSERT INTO prompt_versions (id, name, version, hash, body)
+		VALUES ($1, $2, $3, $4, $5) ON CONFLICT (id) DO NOTHING`, id, name, version, hash, body)
+	if err != nil {
+		return fmt.Errorf("error saving prompt version %s: %v", id, err)
+	}
+	return nil
+}
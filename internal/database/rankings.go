@@ -0,0 +1,29 @@
+package database
+
+import (
+	"fmt"
+
+	"github.com/iamlucif3r/sarjan/internal/types"
+)
+
+// SaveArticleRankings records SARJAN's scores for a ranked list. jobID is
+// nil for dry runs.
+func (s *store) SaveArticleRankings(jobID *int64, ranked []types.JudgedArticle) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	for i, a := range ranked {
+		_, err := tx.Exec(`INSERT INTO article_rankings (article_id, job_id, rank, round, score, rationale)
+			VALUES ($1, $2, $3, $4, $5, $6)`, a.ID, jobID, i+1, a.Round, a.FinalScore, a.Rationale)
+		if err != nil {
+			return fmt.Errorf("error saving ranking for article %d: %v", a.ID, err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("error committing rankings: %v", err)
+	}
+	return nil
+}
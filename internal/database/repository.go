@@ -0,0 +1,86 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/iamlucif3r/sarjan/internal/types"
+)
+
+// Repository is every query SARJAN makes. Postgres is the production
+// implementation; SQLite runs the same pipeline against a local file, or
+// in memory for tests, with articles seeded from fixtures.
+type Repository interface {
+	// Driver is "postgres" or "sqlite".
+	Driver() string
+	Ping(ctx context.Context) error
+	Stats() sql.DBStats
+	Close() error
+
+	// FetchTopRankedArticles reads the upstream articles table.
+	FetchTopRankedArticles(q types.ArticleQuery) ([]types.JudgedArticle, error)
+	// CheckUpstream verifies the articles table has ArticleColumns and
+	// returns the OptionalArticleColumns it lacks.
+	CheckUpstream() ([]string, error)
+
+	CreateJob(req types.GenerateRequest) (types.Job, error)
+	// GetJob returns sql.ErrNoRows for an unknown id.
+	GetJob(id int64) (types.Job, error)
+	ListJobsInState(states ...types.JobState) ([]types.Job, error)
+	SetJobState(id int64, state types.JobState) error
+	SetJobStageError(id int64, stage types.JobState, msg string) error
+	AddJobArtifact(id int64, link string) error
+
+	SaveBundle(bundle types.Bundle) (int64, error)
+	ListBundles(limit, offset int) ([]types.Bundle, error)
+	// GetBundle returns sql.ErrNoRows for an unknown id.
+	GetBundle(id int64) (types.Bundle, error)
+	SaveArticleRankings(jobID *int64, ranked []types.JudgedArticle) error
+	SavePromptVersion(id, name, version, hash, body string) error
+
+	SaveDeliveryAttempt(jobID *int64, a types.DeliveryAttempt) error
+	ListDeliveryAttempts(jobID int64) ([]types.DeliveryAttempt, error)
+
+	// GetCachedContent returns sql.ErrNoRows for a page not fetched yet.
+	GetCachedContent(url string) (types.ExtractedArticle, error)
+	SaveCachedContent(extracted types.ExtractedArticle) error
+
+	MigrateUp() ([]Migration, error)
+	MigrateDown(steps int) ([]Migration, error)
+	MigrationStatus() ([]MigrationState, error)
+	PendingMigrations() ([]Migration, error)
+}
+
+// store holds the queries both databases accept as written. Postgres and
+// SQLite embed it and add the ones that need their own SQL; dialect covers
+// the few spots inside shared queries where they differ.
+type store struct {
+	db      *sql.DB
+	dialect dialect
+}
+
+type dialect struct {
+	name string
+	// int64s and int64sDest encode and decode an ID list column.
+	int64s     func([]int64) any
+	int64sDest func(*[]int64) any
+	// objectAgg builds a JSON object from key and value columns.
+	objectAgg func(key, value string) string
+	// migrationsTable creates schema_migrations; lockMigrations, when set,
+	// serialises migration transactions.
+	migrationsTable string
+	lockMigrations  string
+}
+
+func (s *store) Driver() string { return s.dialect.name }
+
+func (s *store) Ping(ctx context.Context) error { return s.db.PingContext(ctx) }
+
+func (s *store) Stats() sql.DBStats { return s.db.Stats() }
+
+func (s *store) Close() error { return s.db.Close() }
+
+var (
+	_ Repository = (*Postgres)(nil)
+	_ Repository = (*SQLite)(nil)
+)
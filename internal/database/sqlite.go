@@ -0,0 +1,209 @@
+package database
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	_ "modernc.org/sqlite"
+
+	"github.com/iamlucif3r/sarjan/internal/types"
+)
+
+// SQLite is a Repository over a local SQLite file, for development and for
+// tests that should not need a Postgres. It owns an articles table of its
+// own, filled with SeedArticles, in place of TRIKAL's.
+type SQLite struct {
+	store
+}
+
+func NewSQLite(db *sql.DB) *SQLite {
+	return &SQLite{store{db: db, dialect: dialect{
+		name: "sqlite",
+		int64s: func(ids []int64) any {
+			encoded, _ := json.Marshal(ids)
+			return string(encoded)
+		},
+		int64sDest: func(ids *[]int64) any { return jsonInt64s{ids} },
+		objectAgg:  func(key, value string) string { return "json_group_object(" + key + ", " + value + ")" },
+		migrationsTable: `CREATE TABLE IF NOT EXISTS schema_migrations (
+			version    INTEGER PRIMARY KEY,
+			name       TEXT NOT NULL,
+			applied_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+		)`,
+	}}}
+}
+
+// sqliteDSN turns the path from a sqlite: DATABASE_URL into a DSN that
+// enforces foreign keys, waits on locks instead of failing, and takes the
+// write lock when a transaction begins, which serialises migrations.
+func sqliteDSN(path string) string {
+	params := "_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)&_txlock=immediate"
+	if path != ":memory:" {
+		params += "&_pragma=journal_mode(WAL)"
+	}
+	sep := "?"
+	if strings.Contains(path, "?") {
+		sep = "&"
+	}
+	return "file:" + path + sep + params
+}
+
+// FetchTopRankedArticles mirrors the Postgres query. LIKE is already
+// case-insensitive in SQLite, though only for ASCII letters.
+func (s *SQLite) FetchTopRankedArticles(q types.ArticleQuery) ([]types.JudgedArticle, error) {
+	if q.Limit < 1 {
+		return nil, fmt.Errorf("limit must be positive")
+	}
+
+	where := []string{"llm_score IS NOT NULL"}
+	var args []any
+	arg := func(v any) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+	jsonArg := func(v any) string {
+		encoded, _ := json.Marshal(v)
+		return arg(string(encoded))
+	}
+
+	if q.MinScore != nil {
+		where = append(where, "llm_score >= "+arg(*q.MinScore))
+	}
+	if q.PublishedAfter != nil {
+		where = append(where, "published_at >= "+arg(q.PublishedAfter.UTC()))
+	}
+	if len(q.Sources) > 0 {
+		where = append(where, "source IN (SELECT value FROM json_each("+jsonArg(q.Sources)+"))")
+	}
+	if include := likePatterns(q.Include); len(include) > 0 {
+		where = append(where, `EXISTS (SELECT 1 FROM json_each(`+jsonArg(include)+`) p
+			WHERE title LIKE p.value ESCAPE '\' OR description LIKE p.value ESCAPE '\')`)
+	}
+	if exclude := likePatterns(q.Exclude); len(exclude) > 0 {
+		where = append(where, `NOT EXISTS (SELECT 1 FROM json_each(`+jsonArg(exclude)+`) p
+			WHERE title LIKE p.value ESCAPE '\' OR COALESCE(description, '') LIKE p.value ESCAPE '\')`)
+	}
+	if q.ExcludeUsed {
+		where = append(where, "NOT EXISTS (SELECT 1 FROM content_bundles b, json_each(b.article_ids) u WHERE u.value = articles.id)")
+	}
+
+	query := `SELECT id, title, COALESCE(description, ''), link FROM articles WHERE ` + strings.Join(where, " AND ") +
+		` ORDER BY llm_score DESC, id DESC LIMIT ` + arg(q.Limit)
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query articles: %v", err)
+	}
+	defer rows.Close()
+
+	var articles []types.JudgedArticle
+	for rows.Next() {
+		var art types.JudgedArticle
+		if err := rows.Scan(&art.ID, &art.Title, &art.Content, &art.URL); err != nil {
+			return nil, err
+		}
+		articles = append(articles, art)
+	}
+	return articles, rows.Err()
+}
+
+func (s *SQLite) SetJobStageError(id int64, stage types.JobState, msg string) error {
+	_, err := s.db.Exec(`UPDATE jobs SET
+		errors = json_set(errors, '$.' || json_quote($2), $3),
+		updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1`, id, string(stage), msg)
+	if err != nil {
+		return fmt.Errorf("error recording job %d error: %v", id, err)
+	}
+	return nil
+}
+
+func (s *SQLite) AddJobArtifact(id int64, link string) error {
+	_, err := s.db.Exec(`UPDATE jobs SET
+		artifacts = json_insert(artifacts, '$[#]', $2),
+		updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1`, id, link)
+	if err != nil {
+		return fmt.Errorf("error recording job %d artifact: %v", id, err)
+	}
+	return nil
+}
+
+// CheckUpstream checks the local articles table, which the SQLite
+// migrations create, so it only fails before `sarjan migrate up`.
+func (s *SQLite) CheckUpstream() ([]string, error) {
+	rows, err := s.db.Query(`SELECT name FROM pragma_table_info('articles')`)
+	if err != nil {
+		return nil, fmt.Errorf("error inspecting the articles table: %v", err)
+	}
+	defer rows.Close()
+	present := map[string]bool{}
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		present[name] = true
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(present) == 0 {
+		return nil, fmt.Errorf("the articles table was not found; run `sarjan migrate up` and `sarjan seed`")
+	}
+	return checkArticleColumns(present)
+}
+
+// SeedArticles inserts articles into the local articles table, skipping
+// links already present, and returns how many were added.
+func (s *SQLite) SeedArticles(articles []ArticleFixture) (int, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("error starting transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	added := 0
+	for _, a := range articles {
+		var publishedAt *time.Time
+		if a.PublishedAt != nil {
+			utc := a.PublishedAt.UTC()
+			publishedAt = &utc
+		}
+		res, err := tx.Exec(`INSERT INTO articles (title, description, link, llm_score, published_at, source)
+			VALUES ($1, $2, $3, $4, $5, $6) ON CONFLICT (link) DO NOTHING`,
+			a.Title, a.Description, a.Link, a.LLMScore, publishedAt, a.Source)
+		if err != nil {
+			return 0, fmt.Errorf("error seeding article %q: %v", a.Link, err)
+		}
+		n, _ := res.RowsAffected()
+		added += int(n)
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("error committing articles: %v", err)
+	}
+	return added, nil
+}
+
+// jsonInt64s scans a JSON array column into an ID list.
+type jsonInt64s struct {
+	dest *[]int64
+}
+
+func (j jsonInt64s) Scan(src any) error {
+	var raw []byte
+	switch v := src.(type) {
+	case nil:
+		*j.dest = nil
+		return nil
+	case string:
+		raw = []byte(v)
+	case []byte:
+		raw = v
+	default:
+		return fmt.Errorf("cannot scan %T into an ID list", src)
+	}
+	return json.Unmarshal(raw, j.dest)
+}
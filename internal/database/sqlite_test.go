@@ -0,0 +1,361 @@
+package database
+
+import (
+	"database/sql"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/iamlucif3r/sarjan/internal/types"
+)
+
+// newTestSQLite opens a private in-memory database through ConnectDB and
+// migrates it.
+func newTestSQLite(t *testing.T) *SQLite {
+	t.Helper()
+	repo, err := ConnectDB(types.Config{DatabaseURL: "sqlite::memory:", DBPingTimeout: time.Second})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { repo.Close() })
+	if _, err := repo.MigrateUp(); err != nil {
+		t.Fatal(err)
+	}
+	return repo.(*SQLite)
+}
+
+func score(v float64) *float64 { return &v }
+
+func ago(d time.Duration) *time.Time {
+	at := time.Now().Add(-d)
+	return &at
+}
+
+func TestMigrationsMatchAcrossDrivers(t *testing.T) {
+	pg, err := Migrations("postgres")
+	if err != nil {
+		t.Fatal(err)
+	}
+	lite, err := Migrations("sqlite")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pg) != len(lite) {
+		t.Fatalf("postgres has %d migrations, sqlite %d", len(pg), len(lite))
+	}
+	for i := range pg {
+		if pg[i].Version != lite[i].Version || pg[i].Name != lite[i].Name {
+			t.Errorf("migration %d: postgres %d_%s, sqlite %d_%s", i, pg[i].Version, pg[i].Name, lite[i].Version, lite[i].Name)
+		}
+		if lite[i].Down == "" {
+			t.Errorf("sqlite migration %d_%s has no .down.sql", lite[i].Version, lite[i].Name)
+		}
+	}
+}
+
+func TestSQLiteMigrateUpDown(t *testing.T) {
+	repo := newTestSQLite(t)
+	all, _ := Migrations("sqlite")
+
+	if again, err := repo.MigrateUp(); err != nil || len(again) != 0 {
+		t.Fatalf("second MigrateUp = %v, %v; want nothing to apply", again, err)
+	}
+	states, err := repo.MigrationStatus()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range states {
+		if s.AppliedAt == nil || s.Unknown {
+			t.Errorf("after MigrateUp, migration %d_%s = %+v", s.Version, s.Name, s)
+		}
+	}
+	if missing, err := repo.CheckUpstream(); err != nil || len(missing) != 0 {
+		t.Errorf("CheckUpstream = %v, %v", missing, err)
+	}
+
+	reverted, err := repo.MigrateDown(len(all))
+	if err != nil || len(reverted) != len(all) {
+		t.Fatalf("MigrateDown = %d reverted, %v; want %d", len(reverted), err, len(all))
+	}
+	if reverted[0].Version != all[len(all)-1].Version {
+		t.Errorf("MigrateDown reverted %d first, want the newest", reverted[0].Version)
+	}
+	if _, err := repo.CheckUpstream(); err == nil {
+		t.Error("CheckUpstream succeeded with no articles table")
+	}
+	if pending, err := repo.PendingMigrations(); err != nil || len(pending) != len(all) {
+		t.Errorf("PendingMigrations after reverting = %d, %v", len(pending), err)
+	}
+	if applied, err := repo.MigrateUp(); err != nil || len(applied) != len(all) {
+		t.Errorf("MigrateUp after reverting = %d, %v", len(applied), err)
+	}
+}
+
+func TestSeedArticles(t *testing.T) {
+	repo := newTestSQLite(t)
+	fixtures, err := DefaultFixtures()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if added, err := repo.SeedArticles(fixtures); err != nil || added != len(fixtures) {
+		t.Fatalf("SeedArticles = %d, %v; want %d", added, err, len(fixtures))
+	}
+	if added, err := repo.SeedArticles(fixtures); err != nil || added != 0 {
+		t.Errorf("seeding again = %d, %v; want links already present to be skipped", added, err)
+	}
+	articles, err := repo.FetchTopRankedArticles(types.ArticleQuery{Limit: 100})
+	if err != nil || len(articles) != len(fixtures) {
+		t.Errorf("FetchTopRankedArticles = %d articles, %v", len(articles), err)
+	}
+
+	path := filepath.Join(t.TempDir(), "bad.json")
+	os.WriteFile(path, []byte(`[{"title": "t", "link": "https://example.com/x", "age": "yesterday"}]`), 0644)
+	if _, err := LoadFixtures(path); err == nil {
+		t.Error("LoadFixtures accepted an invalid age")
+	}
+}
+
+func TestSQLiteArticleQuery(t *testing.T) {
+	repo := newTestSQLite(t)
+	_, err := repo.SeedArticles([]ArticleFixture{
+		{Title: "Zero-day in 100% of routers", Link: "https://example.com/a", LLMScore: score(9), Source: "alpha", PublishedAt: ago(2 * time.Hour)},
+		{Title: "snake_case config leak", Description: "A RANSOMWARE crew found it", Link: "https://example.com/b", LLMScore: score(8), Source: "beta", PublishedAt: ago(30 * time.Hour)},
+		{Title: "Ransomware hits snakeXcase corp", Link: "https://example.com/c", LLMScore: score(7), Source: "alpha", PublishedAt: ago(50 * time.Hour)},
+		{Title: "Not judged yet", Link: "https://example.com/d", Source: "alpha", PublishedAt: ago(1 * time.Hour)},
+		{Title: "100 routers patched", Link: "https://example.com/e", LLMScore: score(6), Source: "gamma", PublishedAt: ago(1 * time.Hour)},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	titles := func(q types.ArticleQuery) []string {
+		t.Helper()
+		articles, err := repo.FetchTopRankedArticles(q)
+		if err != nil {
+			t.Fatalf("FetchTopRankedArticles(%+v) = %v", q, err)
+		}
+		var out []string
+		for _, a := range articles {
+			out = append(out, a.Title)
+		}
+		return out
+	}
+	a, b, c, e := "Zero-day in 100% of routers", "snake_case config leak", "Ransomware hits snakeXcase corp", "100 routers patched"
+	day := time.Now().Add(-24 * time.Hour)
+
+	cases := []struct {
+		name string
+		q    types.ArticleQuery
+		want []string
+	}{
+		{"limit", types.ArticleQuery{Limit: 2}, []string{a, b}},
+		{"unjudged skipped", types.ArticleQuery{Limit: 10}, []string{a, b, c, e}},
+		{"min score", types.ArticleQuery{Limit: 10, MinScore: score(7.5)}, []string{a, b}},
+		{"since", types.ArticleQuery{Limit: 10, PublishedAfter: &day}, []string{a, e}},
+		{"sources", types.ArticleQuery{Limit: 10, Sources: []string{"alpha", "gamma"}}, []string{a, c, e}},
+		{"percent is literal", types.ArticleQuery{Limit: 10, Include: []string{"100%"}}, []string{a}},
+		{"underscore is literal", types.ArticleQuery{Limit: 10, Include: []string{"snake_case"}}, []string{b}},
+		{"include is case-insensitive and reads descriptions", types.ArticleQuery{Limit: 10, Include: []string{"ransomware"}}, []string{b, c}},
+		{"include any keyword", types.ArticleQuery{Limit: 10, Include: []string{"zero-day", "patched"}}, []string{a, e}},
+		{"exclude", types.ArticleQuery{Limit: 10, Exclude: []string{"Ransomware"}}, []string{a, e}},
+		{"filters combine", types.ArticleQuery{Limit: 10, Sources: []string{"alpha"}, Exclude: []string{"routers"}}, []string{c}},
+	}
+	for _, tc := range cases {
+		if got := titles(tc.q); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%s: got %q, want %q", tc.name, got, tc.want)
+		}
+	}
+
+	if _, err := repo.FetchTopRankedArticles(types.ArticleQuery{}); err == nil {
+		t.Error("a zero limit was accepted")
+	}
+
+	// Articles a bundle was generated from are skipped by exclude_used.
+	used := repo.mustArticleIDs(t, a, c)
+	if _, err := repo.SaveBundle(types.Bundle{Brand: "acme", ArticleIDs: used, Content: &types.ContentIdeas{}}); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := titles(types.ArticleQuery{Limit: 10, ExcludeUsed: true}), []string{b, e}; !reflect.DeepEqual(got, want) {
+		t.Errorf("exclude_used: got %q, want %q", got, want)
+	}
+}
+
+// mustArticleIDs looks up article ids by title.
+func (s *SQLite) mustArticleIDs(t *testing.T, titles ...string) []int64 {
+	t.Helper()
+	var ids []int64
+	for _, title := range titles {
+		var id int64
+		if err := s.db.QueryRow(`SELECT id FROM articles WHERE title = $1`, title).Scan(&id); err != nil {
+			t.Fatalf("article %q: %v", title, err)
+		}
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+func TestSQLiteBundleLineage(t *testing.T) {
+	repo := newTestSQLite(t)
+	job, err := repo.CreateJob(types.GenerateRequest{Brand: "acme"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	content := &types.ContentIdeas{
+		TwitterPosts:   []string{"first tweet", "second tweet 🔥"},
+		TwitterThreads: []types.TwitterThread{{Title: "A thread", Tweets: []string{"1/", "2/"}}},
+		LinkedInPosts:  []string{"A post"},
+	}
+	saved := types.Bundle{
+		JobID:         &job.ID,
+		Brand:         "acme",
+		Model:         "llama3",
+		PromptVersion: "generate@v2",
+		ArticleIDs:    []int64{3, 1, 2},
+		Content:       content,
+		Flags:         []types.ContentFlag{{Field: "twitter_posts", Index: 1, Path: "twitter_posts[1]", Rule: "length", Message: "too long"}},
+		Errors:        map[string]string{"youtube": "timed out"},
+	}
+	id, err := repo.SaveBundle(saved)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := repo.GetBundle(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.ID != id || got.JobID == nil || *got.JobID != job.ID || got.Brand != "acme" || got.Model != "llama3" || got.PromptVersion != "generate@v2" {
+		t.Errorf("bundle metadata = %+v", got)
+	}
+	if !reflect.DeepEqual(got.ArticleIDs, saved.ArticleIDs) {
+		t.Errorf("ArticleIDs = %v, want %v in the order saved", got.ArticleIDs, saved.ArticleIDs)
+	}
+	if want := map[string]int{"twitter_posts": 2, "twitter_threads": 1, "linkedin_posts": 1}; !reflect.DeepEqual(got.ItemCounts, want) {
+		t.Errorf("ItemCounts = %v, want %v", got.ItemCounts, want)
+	}
+	if !reflect.DeepEqual(got.Content.TwitterPosts, content.TwitterPosts) || !reflect.DeepEqual(got.Content.TwitterThreads, content.TwitterThreads) ||
+		!reflect.DeepEqual(got.Content.LinkedInPosts, content.LinkedInPosts) {
+		t.Errorf("Content = %+v, want %+v", got.Content, content)
+	}
+	if !reflect.DeepEqual(got.Flags, saved.Flags) || !reflect.DeepEqual(got.Errors, saved.Errors) {
+		t.Errorf("Flags = %+v, Errors = %v", got.Flags, got.Errors)
+	}
+	if got.CreatedAt.IsZero() {
+		t.Error("CreatedAt not set")
+	}
+
+	second, err := repo.SaveBundle(types.Bundle{Brand: "other", Content: &types.ContentIdeas{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	list, err := repo.ListBundles(10, 0)
+	if err != nil || len(list) != 2 || list[0].ID != second || list[1].ID != id {
+		t.Fatalf("ListBundles = %+v, %v; want newest first", list, err)
+	}
+	if list[1].Content != nil || list[1].ItemCounts["twitter_posts"] != 2 {
+		t.Errorf("listed bundle = %+v; want counts without item bodies", list[1])
+	}
+	if page, _ := repo.ListBundles(1, 1); len(page) != 1 || page[0].ID != id {
+		t.Errorf("ListBundles(1, 1) = %+v", page)
+	}
+
+	if _, err := repo.GetBundle(id + 100); err != sql.ErrNoRows {
+		t.Errorf("GetBundle(unknown) = %v, want sql.ErrNoRows", err)
+	}
+	if _, err := repo.SaveBundle(types.Bundle{Brand: "acme"}); err == nil {
+		t.Error("SaveBundle accepted a bundle without content")
+	}
+}
+
+func TestSQLiteJobStates(t *testing.T) {
+	repo := newTestSQLite(t)
+	req := types.GenerateRequest{Brand: "acme", Platforms: []string{"twitter"}, Articles: types.ArticleQuery{Limit: 3}}
+	job, err := repo.CreateJob(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if job.State != types.JobQueued || job.StartedAt != nil || job.FinishedAt != nil || !reflect.DeepEqual(job.Request, req) {
+		t.Fatalf("new job = %+v", job)
+	}
+	other, _ := repo.CreateJob(types.GenerateRequest{Brand: "other"})
+
+	for _, state := range []types.JobState{types.JobFetching, types.JobRanking} {
+		if err := repo.SetJobState(job.ID, state); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := repo.SetJobStageError(job.ID, types.JobRanking, "judge timed out"); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.SetJobStageError(job.ID, `odd "stage"`, "quoted"); err != nil {
+		t.Fatal(err)
+	}
+	for _, link := range []string{"/output/a.pdf", "/output/a.md"} {
+		if err := repo.AddJobArtifact(job.ID, link); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	running, err := repo.GetJob(job.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if running.State != types.JobRanking || running.StartedAt == nil || running.FinishedAt != nil {
+		t.Errorf("running job = %+v", running)
+	}
+	if want := map[string]string{"ranking": "judge timed out", `odd "stage"`: "quoted"}; !reflect.DeepEqual(running.Errors, want) {
+		t.Errorf("Errors = %v, want %v", running.Errors, want)
+	}
+	if want := []string{"/output/a.pdf", "/output/a.md"}; !reflect.DeepEqual(running.Artifacts, want) {
+		t.Errorf("Artifacts = %v, want %v", running.Artifacts, want)
+	}
+
+	if err := repo.SetJobState(job.ID, types.JobDone); err != nil {
+		t.Fatal(err)
+	}
+	done, _ := repo.GetJob(job.ID)
+	if done.FinishedAt == nil || done.StartedAt == nil || !done.StartedAt.Equal(*running.StartedAt) {
+		t.Errorf("done job = %+v; want finished_at set and started_at kept", done)
+	}
+
+	queued, err := repo.ListJobsInState(types.JobQueued, types.JobFetching)
+	if err != nil || len(queued) != 1 || queued[0].ID != other.ID {
+		t.Errorf("ListJobsInState(queued, fetching) = %+v, %v", queued, err)
+	}
+	if none, err := repo.ListJobsInState(); err != nil || none != nil {
+		t.Errorf("ListJobsInState() = %v, %v", none, err)
+	}
+	if _, err := repo.GetJob(job.ID + 100); err != sql.ErrNoRows {
+		t.Errorf("GetJob(unknown) = %v, want sql.ErrNoRows", err)
+	}
+}
+
+func TestSQLiteDeliveriesAndCache(t *testing.T) {
+	repo := newTestSQLite(t)
+	job, _ := repo.CreateJob(types.GenerateRequest{})
+	at := time.Now().UTC().Truncate(time.Second)
+	for i, status := range []int{429, 204} {
+		a := types.DeliveryAttempt{Sink: "discord", Target: "https://discord.com/api/webhooks/1/redacted", Attempt: i + 1, StatusCode: status, At: at}
+		if err := repo.SaveDeliveryAttempt(&job.ID, a); err != nil {
+			t.Fatal(err)
+		}
+	}
+	attempts, err := repo.ListDeliveryAttempts(job.ID)
+	if err != nil || len(attempts) != 2 || attempts[0].StatusCode != 429 || attempts[1].Attempt != 2 || attempts[0].Sink != "discord" {
+		t.Errorf("ListDeliveryAttempts = %+v, %v", attempts, err)
+	}
+
+	if _, err := repo.GetCachedContent("https://example.com/a"); err != sql.ErrNoRows {
+		t.Errorf("GetCachedContent(unfetched) = %v, want sql.ErrNoRows", err)
+	}
+	for _, text := range []string{"first", "second"} {
+		if err := repo.SaveCachedContent(types.ExtractedArticle{URL: "https://example.com/a", Title: "A", Text: text}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if cached, err := repo.GetCachedContent("https://example.com/a"); err != nil || cached.Text != "second" {
+		t.Errorf("GetCachedContent = %+v, %v; want the latest save", cached, err)
+	}
+}
@@ -0,0 +1,35 @@
+package database
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ArticleColumns are the columns of TRIKAL's articles table that every
+// article query reads.
+var ArticleColumns = []string{"id", "title", "description", "link", "llm_score"}
+
+// OptionalArticleColumns are only read by the since and source filters.
+var OptionalArticleColumns = []string{"published_at", "source"}
+
+// checkArticleColumns fails when a required column is missing from the
+// articles columns that were found, and returns the missing optional ones.
+func checkArticleColumns(present map[string]bool) ([]string, error) {
+	if len(present) == 0 {
+		return nil, fmt.Errorf("the articles table was not found; SARJAN reads the articles TRIKAL collects, so point DATABASE_URL at TRIKAL's database and make sure TRIKAL has run")
+	}
+	missing := func(columns []string) []string {
+		var out []string
+		for _, c := range columns {
+			if !present[c] {
+				out = append(out, c)
+			}
+		}
+		return out
+	}
+	if m := missing(ArticleColumns); len(m) > 0 {
+		return nil, fmt.Errorf("the articles table is missing column(s) %s that SARJAN needs (it expects %s); upgrade TRIKAL or check DATABASE_URL",
+			strings.Join(m, ", "), strings.Join(ArticleColumns, ", "))
+	}
+	return missing(OptionalArticleColumns), nil
+}
@@ -1,14 +1,61 @@
 package types
 
+import "time"
+
 type Article struct {
-	ID      int
-	Title   string
-	Content string
-	URL     string
+	ID      int    `json:"id"`
+	Title   string `json:"title"`
+	Content string `json:"content"`
+	URL     string `json:"url"`
 }
 
 type JudgedArticle struct {
 	Article
-	Score      int
-	FinalScore float64
+	Score      int     `json:"score"`
+	FinalScore float64 `json:"final_score"`
+	Rationale  string  `json:"rationale,omitempty"`
+	// Round is the tournament round in which Score was assigned; articles
+	// that reach later rounds outrank those eliminated earlier.
+	Round int `json:"round,omitempty"`
+}
+
+// ArticleQuery selects upstream articles for generation or ranking. Zero
+// values disable the corresponding filter.
+type ArticleQuery struct {
+	Limit          int        `json:"limit"`
+	MinScore       *float64   `json:"min_score,omitempty"`
+	PublishedAfter *time.Time `json:"published_after,omitempty"`
+	Sources        []string   `json:"sources,omitempty"`
+	Include        []string   `json:"include,omitempty"`
+	Exclude        []string   `json:"exclude,omitempty"`
+	// ExcludeUsed skips articles already referenced by a stored bundle.
+	ExcludeUsed bool `json:"exclude_used,omitempty"`
+}
+
+// GenerateRequest is everything a caller can ask of one /generate run. It
+// is stored with the job so a restarted worker sees the same request.
+type GenerateRequest struct {
+	Articles ArticleQuery `json:"articles"`
+	// Brand is a brand profile ID; empty selects the default brand.
+	Brand string `json:"brand,omitempty"`
+	// Platforms restricts generation to these platforms; empty means every
+	// platform unless Counts names some fields.
+	Platforms []string `json:"platforms,omitempty"`
+	// Counts overrides how many items to generate per ContentIdeas field,
+	// e.g. {"twitter_posts": 10}. Zero drops a field.
+	Counts map[string]int `json:"counts,omitempty"`
+	// Formats are the output formats to render, e.g. ["pdf", "markdown"].
+	// Empty uses RENDER_FORMATS.
+	Formats []string `json:"formats,omitempty"`
+}
+
+// ExtractedArticle is the readable content and metadata pulled from an
+// article's web page.
+type ExtractedArticle struct {
+	URL          string     `json:"url"`
+	Title        string     `json:"title"`
+	Author       string     `json:"author,omitempty"`
+	PublishedAt  *time.Time `json:"published_at,omitempty"`
+	CanonicalURL string     `json:"canonical_url,omitempty"`
+	Text         string     `json:"text"`
 }
@@ -0,0 +1,28 @@
+package types
+
+// BrandProfile is the persona, house rules and delivery settings for one
+// channel SARJAN writes for.
+type BrandProfile struct {
+	ID       string `yaml:"id" json:"id"`
+	Name     string `yaml:"name" json:"name"`
+	Voice    string `yaml:"voice" json:"voice"`
+	Audience string `yaml:"audience" json:"audience,omitempty"`
+	Goal     string `yaml:"goal" json:"goal,omitempty"`
+	// EmojiPolicy is free text such as "none", "sparing" or "liberal".
+	EmojiPolicy string   `yaml:"emoji_policy" json:"emoji_policy,omitempty"`
+	BannedWords []string `yaml:"banned_words" json:"banned_words,omitempty"`
+	Hashtags    []string `yaml:"hashtags" json:"hashtags,omitempty"`
+	// PlatformStyles holds extra direction keyed by youtube, twitter,
+	// linkedin or instagram.
+	PlatformStyles    map[string]string `yaml:"platform_styles" json:"platform_styles,omitempty"`
+	DiscordWebhookURL string            `yaml:"discord_webhook_url" json:"-"`
+	// Notifiers lists where finished bundles are sent. Without any, a
+	// Discord webhook URL becomes the only sink.
+	Notifiers []NotifierConfig `yaml:"notifiers" json:"notifiers,omitempty"`
+	OutputDir string           `yaml:"output_dir" json:"output_dir"`
+}
+
+// String lets templates written against a plain brand name keep working.
+func (b BrandProfile) String() string {
+	return b.Name
+}
@@ -0,0 +1,19 @@
+package types
+
+import "time"
+
+type Bundle struct {
+	ID            int64          `json:"id"`
+	JobID         *int64         `json:"job_id,omitempty"`
+	Brand         string         `json:"brand"`
+	Model         string         `json:"model"`
+	PromptVersion string         `json:"prompt_version"`
+	ArticleIDs    []int64        `json:"article_ids"`
+	ItemCounts    map[string]int `json:"item_counts"`
+	Content       *ContentIdeas  `json:"content,omitempty"`
+	// Flags are platform rules items still break after rewriting.
+	Flags []ContentFlag `json:"flags,omitempty"`
+	// Errors holds the platforms that failed to generate, by name.
+	Errors    map[string]string `json:"errors,omitempty"`
+	CreatedAt time.Time         `json:"created_at"`
+}
@@ -1,8 +1,84 @@
 package types
 
+import "time"
+
+// Config is SARJAN's settings. config.Load fills it from the struct tags:
+// env names the variable (and, lowercased, the config file key), default
+// is used when nothing sets it, secret masks the value when printed
+// ("true" hides it, "url" hides the URL's credentials), and required, min,
+// oneof and url are checked before startup.
 type Config struct {
-	DatabaseURL       string `json:"db_url"`
-	OllamaURL         string `json:"ollama_url"`
-	DiscordWebhookURL string `json:"discord_webhook_url"`
-	OllamaModel       string `json:"ollama_model"`
+	DatabaseURL string `json:"db_url" env:"DATABASE_URL" required:"true" url:"database" secret:"url"`
+	// MigrateOnStart applies pending migrations at startup; when off,
+	// startup refuses to run until `sarjan migrate up` has.
+	MigrateOnStart bool `json:"migrate_on_start" env:"MIGRATE_ON_START" default:"true"`
+	// Pool limits; a zero lifetime or idle time keeps connections forever.
+	DBMaxOpenConns    int           `json:"db_max_open_conns" env:"DB_MAX_OPEN_CONNS" default:"10" min:"1"`
+	DBMaxIdleConns    int           `json:"db_max_idle_conns" env:"DB_MAX_IDLE_CONNS" default:"5" min:"0"`
+	DBConnMaxLifetime time.Duration `json:"db_conn_max_lifetime" env:"DB_CONN_MAX_LIFETIME" default:"30m" min:"0s"`
+	DBConnMaxIdleTime time.Duration `json:"db_conn_max_idle_time" env:"DB_CONN_MAX_IDLE_TIME" default:"5m" min:"0s"`
+	DBPingTimeout     time.Duration `json:"db_ping_timeout" env:"DB_PING_TIMEOUT" default:"5s" min:"1ms"`
+	// HealthCheckTimeout bounds each /health/ready check.
+	HealthCheckTimeout time.Duration `json:"health_check_timeout" env:"HEALTH_CHECK_TIMEOUT" default:"3s" min:"1ms"`
+
+	OllamaURL         string `json:"ollama_url" env:"OLLAMA_URL" url:"http"`
+	DiscordWebhookURL string `json:"discord_webhook_url" env:"DISCORD_WEBHOOK_URL" url:"http" secret:"url"`
+	DiscordDelivery   string `json:"discord_delivery" env:"DISCORD_DELIVERY" default:"embeds" oneof:"embeds attachments"`
+	DiscordThreads    bool   `json:"discord_threads" env:"DISCORD_THREADS"`
+	OllamaModel       string `json:"ollama_model" env:"LLM_MODEL" required:"true"`
+	LLMProvider       string `json:"llm_provider" env:"LLM_PROVIDER" default:"ollama" oneof:"ollama openai"`
+	// LLMBaseURL falls back to OllamaURL.
+	LLMBaseURL            string   `json:"llm_base_url" env:"LLM_BASE_URL" url:"http"`
+	LLMAPIKey             string   `json:"llm_api_key" env:"LLM_API_KEY" secret:"true"`
+	GenerationAttempts    int      `json:"generation_attempts" env:"GENERATION_MAX_ATTEMPTS" default:"3" min:"1"`
+	GenerationConcurrency int      `json:"generation_concurrency" env:"GENERATION_CONCURRENCY" default:"2" min:"1"`
+	RenderFormats         []string `json:"render_formats" env:"RENDER_FORMATS" default:"pdf"`
+	PDFEmojiFont          string   `json:"pdf_emoji_font" env:"PDF_EMOJI_FONT"`
+	LLMContextWindow      int      `json:"llm_context_window" env:"LLM_CONTEXT_WINDOW" default:"0" min:"0"`
+	LLMOutputReserve      int      `json:"llm_output_reserve" env:"LLM_OUTPUT_RESERVE" default:"2048" min:"0"`
+	PromptSummarise       bool     `json:"prompt_summarise" env:"PROMPT_SUMMARISE" default:"true"`
+	// LLMTimeout bounds each request to the model, streaming included.
+	LLMTimeout time.Duration `json:"llm_timeout" env:"LLM_TIMEOUT" default:"5m" min:"1s"`
+	// JobTimeout bounds a whole generate job, so a stuck stage fails the
+	// job instead of holding the queue worker.
+	JobTimeout time.Duration `json:"job_timeout" env:"JOB_TIMEOUT" default:"30m" min:"1s"`
+
+	WebhookMaxAttempts int           `json:"webhook_max_attempts" env:"WEBHOOK_MAX_ATTEMPTS" default:"5" min:"1"`
+	WebhookRetryBase   time.Duration `json:"webhook_retry_base" env:"WEBHOOK_RETRY_BASE" default:"1s" min:"1ms"`
+	WebhookRetryMax    time.Duration `json:"webhook_retry_max" env:"WEBHOOK_RETRY_MAX" default:"30s" min:"1ms"`
+
+	SMTPHost     string `json:"smtp_host" env:"SMTP_HOST"`
+	SMTPPort     int    `json:"smtp_port" env:"SMTP_PORT" default:"587" min:"1"`
+	SMTPUsername string `json:"smtp_username" env:"SMTP_USERNAME"`
+	SMTPPassword string `json:"smtp_password" env:"SMTP_PASSWORD" secret:"true"`
+	SMTPFrom     string `json:"smtp_from" env:"SMTP_FROM"`
+
+	BrandsFile   string `json:"brands_file" env:"BRANDS_FILE" default:"brands.yaml"`
+	DefaultBrand string `json:"default_brand" env:"DEFAULT_BRAND"`
+
+	PromptsDir           string            `json:"prompts_dir" env:"PROMPTS_DIR" default:"prompts"`
+	PromptPins           map[string]string `json:"prompt_pins" env:"PROMPT_VERSIONS"`
+	PromptReloadInterval time.Duration     `json:"prompt_reload_interval" env:"PROMPT_RELOAD_INTERVAL" default:"30s" min:"0s"`
+
+	RerankEnabled    bool `json:"rerank_enabled" env:"RERANK_ENABLED" default:"false"`
+	RerankCandidates int  `json:"rerank_candidates" env:"RERANK_CANDIDATES" default:"20" min:"1"`
+	RerankBatchSize  int  `json:"rerank_batch_size" env:"RERANK_BATCH_SIZE" default:"8" min:"2"`
+	RerankWinners    int  `json:"rerank_winners" env:"RERANK_WINNERS" default:"3" min:"1"`
+	EnrichEnabled    bool `json:"enrich_enabled" env:"ENRICH_ENABLED" default:"true"`
+
+	EnrichConcurrency int           `json:"enrich_concurrency" env:"ENRICH_CONCURRENCY" default:"4" min:"1"`
+	EnrichHostDelay   time.Duration `json:"enrich_host_delay" env:"ENRICH_HOST_DELAY" default:"1s" min:"0s"`
+
+	FetchConnectTimeout time.Duration `json:"fetch_connect_timeout" env:"FETCH_CONNECT_TIMEOUT" default:"5s" min:"1ms"`
+	FetchTimeout        time.Duration `json:"fetch_timeout" env:"FETCH_TIMEOUT" default:"20s" min:"1ms"`
+	FetchMaxBytes       int64         `json:"fetch_max_bytes" env:"FETCH_MAX_BYTES" default:"5242880" min:"1"`
+	FetchMaxRedirects   int           `json:"fetch_max_redirects" env:"FETCH_MAX_REDIRECTS" default:"5" min:"0"`
+	FetchUserAgent      string        `json:"fetch_user_agent" env:"FETCH_USER_AGENT"`
+	FetchAllowlist      []string      `json:"fetch_allowlist" env:"FETCH_ALLOWLIST"`
+	FetchRespectRobots  bool          `json:"fetch_respect_robots" env:"FETCH_RESPECT_ROBOTS" default:"true"`
+
+	// Env is the environment with .env values beneath it, which ${VAR}
+	// references in the brands file are resolved from. It is not a
+	// setting; config.Load fills it.
+	Env map[string]string `json:"-"`
 }
@@ -1,5 +1,7 @@
 package types
 
+import "encoding/json"
+
 type ContentIdeas struct {
 	InstagramReels []struct {
 		Idea         string `json:"idea"`
@@ -10,10 +12,7 @@ type ContentIdeas struct {
 
 	TwitterPosts []string `json:"twitter_posts"`
 
-	TwitterThreads []struct {
-		Title string   `json:"title"`
-		Body  []string `json:"body"`
-	} `json:"twitter_threads"`
+	TwitterThreads []TwitterThread `json:"twitter_threads"`
 
 	LinkedInPosts []string `json:"linkedin_posts"`
 
@@ -23,3 +22,62 @@ type ContentIdeas struct {
 		BulletPoints []string `json:"bullet_points"`
 	} `json:"youtube_video_ideas"`
 }
+
+// TwitterThread is one thread: a title and its tweets in order.
+type TwitterThread struct {
+	Title  string   `json:"title"`
+	Tweets []string `json:"tweets"`
+}
+
+// UnmarshalJSON also accepts the "body" key that bundles stored before the
+// field matched the prompt were written with.
+func (t *TwitterThread) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		Title  string   `json:"title"`
+		Tweets []string `json:"tweets"`
+		Body   []string `json:"body"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	t.Title = raw.Title
+	t.Tweets = raw.Tweets
+	if len(t.Tweets) == 0 {
+		t.Tweets = raw.Body
+	}
+	return nil
+}
+
+// ContentFlag is one platform rule a generated item breaks. Field and
+// Index identify the item; Path points at the offending text within it.
+type ContentFlag struct {
+	Field   string `json:"field"`
+	Index   int    `json:"index"`
+	Path    string `json:"path"`
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+// Platforms is the order platforms are generated and rendered in.
+var Platforms = []string{"youtube", "twitter", "linkedin", "instagram"}
+
+// PlatformFields lists the ContentIdeas JSON fields each platform owns.
+var PlatformFields = map[string][]string{
+	"youtube":   {"youtube_video_ideas"},
+	"twitter":   {"twitter_posts", "twitter_threads"},
+	"linkedin":  {"linkedin_posts"},
+	"instagram": {"instagram_reels", "instagram_posts"},
+}
+
+// PlatformOf returns the platform a ContentIdeas field belongs to, or ""
+// for an unknown field.
+func PlatformOf(field string) string {
+	for platform, fields := range PlatformFields {
+		for _, f := range fields {
+			if f == field {
+				return platform
+			}
+		}
+	}
+	return ""
+}
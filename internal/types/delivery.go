@@ -0,0 +1,57 @@
+package types
+
+import "time"
+
+// DeliveryAttempt is one request made to a webhook, successful or not.
+type DeliveryAttempt struct {
+	// Sink names the notifier that made the request.
+	Sink string `json:"sink,omitempty"`
+	// Target is the webhook URL with its secret token masked.
+	Target     string `json:"target"`
+	Attempt    int    `json:"attempt"`
+	StatusCode int    `json:"status_code,omitempty"`
+	MessageID  string `json:"message_id,omitempty"`
+	Error      string `json:"error,omitempty"`
+	// RetryAfterMS is how long the client waited before trying again.
+	RetryAfterMS int64     `json:"retry_after_ms,omitempty"`
+	DurationMS   int64     `json:"duration_ms"`
+	At           time.Time `json:"at"`
+}
+
+// NotifierConfig is one delivery sink for a brand. Type picks the sink
+// (discord, slack, teams, telegram, email or webhook) and decides which of
+// the other fields apply. String fields may reference ${ENV_VARS}.
+type NotifierConfig struct {
+	Type string `yaml:"type" json:"type"`
+	// Name tells sinks of the same type apart in results and defaults to
+	// Type.
+	Name string `yaml:"name" json:"name,omitempty"`
+	// URL is the incoming webhook for discord, slack, teams and webhook,
+	// and an optional Bot API base URL for telegram.
+	URL     string            `yaml:"url" json:"-"`
+	Headers map[string]string `yaml:"headers" json:"-"`
+	// Mode is "embeds" or "attachments" for discord; empty uses
+	// DISCORD_DELIVERY. Thread opens a forum post per bundle.
+	Mode     string   `yaml:"mode" json:"mode,omitempty"`
+	Thread   *bool    `yaml:"thread" json:"thread,omitempty"`
+	BotToken string   `yaml:"bot_token" json:"-"`
+	ChatID   string   `yaml:"chat_id" json:"chat_id,omitempty"`
+	To       []string `yaml:"to" json:"to,omitempty"`
+}
+
+// SinkName is how results and errors refer to the sink.
+func (n NotifierConfig) SinkName() string {
+	if n.Name != "" {
+		return n.Name
+	}
+	return n.Type
+}
+
+// NotifyResult is the outcome of delivering one bundle to one sink.
+type NotifyResult struct {
+	Sink       string `json:"sink"`
+	Type       string `json:"type"`
+	OK         bool   `json:"ok"`
+	Error      string `json:"error,omitempty"`
+	DurationMS int64  `json:"duration_ms"`
+}
@@ -0,0 +1,26 @@
+package types
+
+import "time"
+
+// Health statuses.
+const (
+	HealthOK   = "ok"
+	HealthFail = "fail"
+)
+
+// HealthReport is the readiness of every dependency. Status is HealthOK
+// only when every check passed.
+type HealthReport struct {
+	Status    string                 `json:"status"`
+	CheckedAt time.Time              `json:"checked_at"`
+	Checks    map[string]HealthCheck `json:"checks"`
+}
+
+// HealthCheck is the outcome of checking one dependency.
+type HealthCheck struct {
+	Status    string `json:"status"`
+	LatencyMS int64  `json:"latency_ms"`
+	// Detail says what was checked, e.g. the model that was found.
+	Detail string `json:"detail,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
@@ -0,0 +1,29 @@
+package types
+
+import "time"
+
+type JobState string
+
+const (
+	JobQueued     JobState = "queued"
+	JobFetching   JobState = "fetching"
+	JobRanking    JobState = "ranking"
+	JobEnriching  JobState = "enriching"
+	JobGenerating JobState = "generating"
+	JobRendering  JobState = "rendering"
+	JobDelivering JobState = "delivering"
+	JobDone       JobState = "done"
+	JobFailed     JobState = "failed"
+)
+
+type Job struct {
+	ID         int64             `json:"id"`
+	State      JobState          `json:"state"`
+	Request    GenerateRequest   `json:"request"`
+	Errors     map[string]string `json:"errors"`
+	Artifacts  []string          `json:"artifacts"`
+	CreatedAt  time.Time         `json:"created_at"`
+	UpdatedAt  time.Time         `json:"updated_at"`
+	StartedAt  *time.Time        `json:"started_at,omitempty"`
+	FinishedAt *time.Time        `json:"finished_at,omitempty"`
+}
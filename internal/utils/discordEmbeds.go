@@ -0,0 +1,234 @@
+package utils
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/iamlucif3r/sarjan/internal/types"
+)
+
+// Discord rejects messages past these limits, counted in characters.
+const (
+	discordMaxEmbeds      = 10
+	discordMaxEmbedChars  = 6000 // across every embed in one message
+	discordMaxFields      = 25
+	discordMaxTitle       = 256
+	discordMaxDescription = 4096
+	discordMaxFieldName   = 256
+	discordMaxFieldValue  = 1024
+	discordMaxFooter      = 2048
+	discordMaxThreadName  = 100
+)
+
+var platformColors = map[string]int{
+	"youtube":   0xFF0000,
+	"twitter":   0x1D9BF0,
+	"linkedin":  0x0A66C2,
+	"instagram": 0xE1306C,
+}
+
+const (
+	sourcesColor = 0x656D76
+	errorsColor  = 0xCF222E
+)
+
+// discordMessages packs the bundle's embeds into as few messages as the
+// limits allow. The first message carries the heading and, when set, the
+// thread name.
+func discordMessages(doc Document, threadName string) []types.DiscordMessage {
+	messages := []types.DiscordMessage{{
+		Content:    fmt.Sprintf("**%s**\nGenerated %s", doc.heading(), doc.GeneratedAt.Format("02 Jan 2006 15:04")),
+		ThreadName: threadName,
+	}}
+	size := 0
+	for _, embed := range discordEmbeds(doc) {
+		n := embedChars(embed)
+		last := &messages[len(messages)-1]
+		if len(last.Embeds) == discordMaxEmbeds || (len(last.Embeds) > 0 && size+n > discordMaxEmbedChars) {
+			messages = append(messages, types.DiscordMessage{})
+			last = &messages[len(messages)-1]
+			size = 0
+		}
+		last.Embeds = append(last.Embeds, embed)
+		size += n
+	}
+	return messages
+}
+
+// discordEmbeds lays the bundle out as one embed per platform, continued
+// in further embeds when a platform has too many fields, followed by the
+// sources and anything that was not generated.
+func discordEmbeds(doc Document) []types.DiscordEmbed {
+	var sourceURL string
+	if len(doc.Sources) > 0 {
+		sourceURL = doc.Sources[0].URL
+	}
+	var timestamp string
+	if !doc.GeneratedAt.IsZero() {
+		timestamp = doc.GeneratedAt.UTC().Format(time.RFC3339)
+	}
+	footer := &types.DiscordEmbedFooter{Text: truncateRunes(doc.heading(), discordMaxFooter)}
+
+	var embeds []types.DiscordEmbed
+	for _, section := range doc.sections() {
+		var fields []types.DiscordEmbedField
+		for _, group := range section.Groups {
+			for _, item := range group.Items {
+				name := truncateRunes(group.Title+" · "+item.Label, discordMaxFieldName-len(" (cont.)"))
+				for i, chunk := range splitRunes(discordItemText(item), discordMaxFieldValue) {
+					field := types.DiscordEmbedField{Name: name, Value: chunk}
+					if i > 0 {
+						field.Name += " (cont.)"
+					}
+					fields = append(fields, field)
+				}
+			}
+		}
+		embeds = append(embeds, splitEmbed(types.DiscordEmbed{
+			Title:     section.Title,
+			URL:       sourceURL,
+			Color:     platformColors[section.Platform],
+			Footer:    footer,
+			Timestamp: timestamp,
+		}, fields)...)
+	}
+
+	if len(doc.Sources) > 0 {
+		var b strings.Builder
+		for i, source := range doc.Sources {
+			title := source.Title
+			if title == "" {
+				title = source.URL
+			}
+			fmt.Fprintf(&b, "%d. [%s](%s)\n", i+1, strings.ReplaceAll(title, "]", ")"), source.URL)
+		}
+		for i, chunk := range splitRunes(b.String(), discordMaxDescription) {
+			title := "Sources"
+			if i > 0 {
+				title += " (cont.)"
+			}
+			embeds = append(embeds, types.DiscordEmbed{Title: title, Description: chunk, Color: sourcesColor})
+		}
+	}
+
+	if len(doc.Errors) > 0 {
+		platforms := make([]string, 0, len(doc.Errors))
+		for platform := range doc.Errors {
+			platforms = append(platforms, platform)
+		}
+		sort.Strings(platforms)
+		var fields []types.DiscordEmbedField
+		for _, platform := range platforms {
+			name := platformTitles[platform]
+			if name == "" {
+				name = platform
+			}
+			fields = append(fields, types.DiscordEmbedField{Name: name, Value: truncateRunes(doc.Errors[platform], discordMaxFieldValue)})
+		}
+		embeds = append(embeds, splitEmbed(types.DiscordEmbed{Title: "Not generated", Color: errorsColor}, fields)...)
+	}
+	return embeds
+}
+
+// splitEmbed spreads fields over copies of base so that no embed has more
+// than 25 fields or exceeds the per-message character budget by itself.
+func splitEmbed(base types.DiscordEmbed, fields []types.DiscordEmbedField) []types.DiscordEmbed {
+	var out []types.DiscordEmbed
+	next := base
+	for _, field := range fields {
+		if len(next.Fields) == discordMaxFields || (len(next.Fields) > 0 && embedChars(next)+fieldChars(field) > discordMaxEmbedChars) {
+			out = append(out, next)
+			next = base
+			next.Title = truncateRunes(base.Title, discordMaxTitle-len(" (cont.)")) + " (cont.)"
+		}
+		next.Fields = append(next.Fields, field)
+	}
+	return append(out, next)
+}
+
+// discordItemText renders one docItem as a field value.
+func discordItemText(item docItem) string {
+	var lines []string
+	for _, field := range item.Fields {
+		lines = append(lines, fmt.Sprintf("**%s:** %s", field.Name, field.Value))
+	}
+	if item.Text != "" {
+		lines = append(lines, item.Text)
+	}
+	for _, entry := range item.List {
+		lines = append(lines, "• "+entry)
+	}
+	for _, f := range item.Flags {
+		lines = append(lines, fmt.Sprintf("⚠️ **Needs edit:** `%s` %s", f.Path, f.Message))
+	}
+	text := strings.TrimSpace(strings.Join(lines, "\n"))
+	if text == "" {
+		return "-"
+	}
+	return text
+}
+
+func embedChars(e types.DiscordEmbed) int {
+	n := utf8.RuneCountInString(e.Title) + utf8.RuneCountInString(e.Description)
+	if e.Footer != nil {
+		n += utf8.RuneCountInString(e.Footer.Text)
+	}
+	for _, f := range e.Fields {
+		n += fieldChars(f)
+	}
+	return n
+}
+
+func fieldChars(f types.DiscordEmbedField) int {
+	return utf8.RuneCountInString(f.Name) + utf8.RuneCountInString(f.Value)
+}
+
+// splitRunes cuts s into pieces of at most limit characters, preferring to
+// break at a newline, then a space, in the second half of each piece.
+func splitRunes(s string, limit int) []string {
+	var out []string
+	runes := []rune(s)
+	for len(runes) > limit {
+		cut := limit
+		for _, sep := range []rune{'\n', ' '} {
+			if i := lastRune(runes[:limit], sep); i >= limit/2 {
+				cut = i
+				break
+			}
+		}
+		out = append(out, strings.TrimSpace(string(runes[:cut])))
+		runes = []rune(strings.TrimLeft(string(runes[cut:]), " \n"))
+	}
+	if len(runes) > 0 || len(out) == 0 {
+		out = append(out, string(runes))
+	}
+	return out
+}
+
+func lastRune(runes []rune, r rune) int {
+	for i := len(runes) - 1; i >= 0; i-- {
+		if runes[i] == r {
+			return i
+		}
+	}
+	return -1
+}
+
+func truncateRunes(s string, limit int) string {
+	runes := []rune(s)
+	if len(runes) <= limit {
+		return s
+	}
+	return string(runes[:limit-1]) + "…"
+}
+
+func discordThreadName(doc Document) string {
+	name := doc.heading()
+	if !doc.GeneratedAt.IsZero() {
+		name += " · " + doc.GeneratedAt.Format("02 Jan 2006 15:04")
+	}
+	return truncateRunes(name, discordMaxThreadName)
+}
@@ -0,0 +1,198 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/iamlucif3r/sarjan/internal/types"
+)
+
+// Delivery is one finished bundle: the document and its rendered files.
+type Delivery struct {
+	Doc   Document
+	Files []string
+}
+
+// Notifier sends a Delivery to one sink, formatted the way that sink
+// reads best.
+type Notifier interface {
+	// Name identifies the sink in results, e.g. "discord" or "team-slack".
+	Name() string
+	Type() string
+	Notify(ctx context.Context, d Delivery) error
+}
+
+// NotifierOptions are the process-wide settings sinks fall back on.
+type NotifierOptions struct {
+	// Webhooks sends every HTTP request; its OnAttempt sees each attempt
+	// tagged with the sink name.
+	Webhooks *WebhookClient
+	// DiscordDelivery and DiscordThreads apply to discord sinks that do not
+	// set mode or thread themselves.
+	DiscordDelivery string
+	DiscordThreads  bool
+	SMTP            SMTPSettings
+}
+
+// SMTPSettings is the mail server email sinks send through.
+type SMTPSettings struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     string
+}
+
+// NotifierTypes lists the sink types NewNotifier accepts.
+var NotifierTypes = []string{"discord", "slack", "teams", "telegram", "email", "webhook"}
+
+// ValidateNotifierConfig reports a sink that could never deliver, so bad
+// brand files fail at startup rather than at the end of a job.
+func ValidateNotifierConfig(cfg types.NotifierConfig) error {
+	cfg = normaliseNotifierConfig(cfg)
+	switch cfg.Type {
+	case "discord":
+		if cfg.Mode != "" && cfg.Mode != "embeds" && cfg.Mode != "attachments" {
+			return fmt.Errorf("notifier %s: mode must be embeds or attachments", cfg.SinkName())
+		}
+		fallthrough
+	case "slack", "teams", "webhook":
+		if cfg.URL == "" {
+			return fmt.Errorf("notifier %s: url is required", cfg.SinkName())
+		}
+	case "telegram":
+		if cfg.BotToken == "" || cfg.ChatID == "" {
+			return fmt.Errorf("notifier %s: bot_token and chat_id are required", cfg.SinkName())
+		}
+	case "email":
+		if len(cfg.To) == 0 {
+			return fmt.Errorf("notifier %s: to needs at least one address", cfg.SinkName())
+		}
+	default:
+		return fmt.Errorf("notifier %s: unknown type %q (have %s)", cfg.SinkName(), cfg.Type, strings.Join(NotifierTypes, ", "))
+	}
+	return nil
+}
+
+// NewNotifier builds the sink described by cfg.
+func NewNotifier(cfg types.NotifierConfig, opts NotifierOptions) (Notifier, error) {
+	if err := ValidateNotifierConfig(cfg); err != nil {
+		return nil, err
+	}
+	cfg = normaliseNotifierConfig(cfg)
+	if opts.Webhooks == nil {
+		opts.Webhooks = NewWebhookClient(0, 0, 0)
+	}
+	base := notifierBase{name: cfg.SinkName(), kind: cfg.Type, client: opts.Webhooks.forSink(cfg.SinkName())}
+
+	switch cfg.Type {
+	case "discord":
+		n := discordNotifier{notifierBase: base, url: cfg.URL, mode: cfg.Mode, thread: opts.DiscordThreads}
+		if n.mode == "" {
+			n.mode = opts.DiscordDelivery
+		}
+		if cfg.Thread != nil {
+			n.thread = *cfg.Thread
+		}
+		return n, nil
+	case "slack":
+		return slackNotifier{notifierBase: base, url: cfg.URL}, nil
+	case "teams":
+		return teamsNotifier{notifierBase: base, url: cfg.URL}, nil
+	case "telegram":
+		apiURL := cfg.URL
+		if apiURL == "" {
+			apiURL = "https://api.telegram.org"
+		}
+		return telegramNotifier{notifierBase: base, apiURL: strings.TrimRight(apiURL, "/"), token: cfg.BotToken, chatID: cfg.ChatID}, nil
+	case "email":
+		if opts.SMTP.Host == "" || opts.SMTP.From == "" {
+			return nil, fmt.Errorf("notifier %s: SMTP_HOST and SMTP_FROM must be set", cfg.SinkName())
+		}
+		return emailNotifier{notifierBase: base, smtp: opts.SMTP, to: cfg.To}, nil
+	default:
+		return webhookNotifier{notifierBase: base, url: cfg.URL, headers: cfg.Headers}, nil
+	}
+}
+
+// NotifyAll delivers d to every notifier concurrently and reports each
+// outcome, in the notifiers' order.
+func NotifyAll(ctx context.Context, notifiers []Notifier, d Delivery) []types.NotifyResult {
+	results := make([]types.NotifyResult, len(notifiers))
+	var wg sync.WaitGroup
+	for i, n := range notifiers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			start := time.Now()
+			err := n.Notify(ctx, d)
+			results[i] = types.NotifyResult{Sink: n.Name(), Type: n.Type(), OK: err == nil, DurationMS: time.Since(start).Milliseconds()}
+			if err != nil {
+				results[i].Error = err.Error()
+			}
+		}()
+	}
+	wg.Wait()
+	return results
+}
+
+// notifierBase carries what every sink has in common.
+type notifierBase struct {
+	name   string
+	kind   string
+	client *WebhookClient
+}
+
+func (n notifierBase) Name() string { return n.name }
+func (n notifierBase) Type() string { return n.kind }
+
+// forSink returns a copy of c whose attempts are tagged with the sink.
+func (c *WebhookClient) forSink(name string) *WebhookClient {
+	next := c.OnAttempt
+	return c.Recording(func(a types.DeliveryAttempt) {
+		a.Sink = name
+		if next != nil {
+			next(a)
+		}
+	})
+}
+
+// ExpandNotifierConfig substitutes ${VAR} references, looked up with
+// getenv, so secrets can stay out of the brands file.
+func ExpandNotifierConfig(cfg types.NotifierConfig, getenv func(string) string) types.NotifierConfig {
+	cfg.URL = os.Expand(cfg.URL, getenv)
+	cfg.BotToken = os.Expand(cfg.BotToken, getenv)
+	cfg.ChatID = os.Expand(cfg.ChatID, getenv)
+	if len(cfg.Headers) > 0 {
+		headers := make(map[string]string, len(cfg.Headers))
+		for k, v := range cfg.Headers {
+			headers[k] = os.Expand(v, getenv)
+		}
+		cfg.Headers = headers
+	}
+	if len(cfg.To) > 0 {
+		to := make([]string, len(cfg.To))
+		for i, addr := range cfg.To {
+			to[i] = os.Expand(addr, getenv)
+		}
+		cfg.To = to
+	}
+	return cfg
+}
+
+// normaliseNotifierConfig lowercases the type and drops blank addresses.
+func normaliseNotifierConfig(cfg types.NotifierConfig) types.NotifierConfig {
+	cfg.Type = strings.ToLower(strings.TrimSpace(cfg.Type))
+	to := make([]string, 0, len(cfg.To))
+	for _, addr := range cfg.To {
+		if addr = strings.TrimSpace(addr); addr != "" {
+			to = append(to, addr)
+		}
+	}
+	cfg.To = to
+	return cfg
+}
@@ -0,0 +1,95 @@
+package utils
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/iamlucif3r/sarjan/internal/types"
+)
+
+// capturePosts serves a webhook that records every request body.
+func capturePosts(t *testing.T) (*httptest.Server, func() [][]byte) {
+	t.Helper()
+	var mu sync.Mutex
+	var bodies [][]byte
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		bodies = append(bodies, body)
+		mu.Unlock()
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, func() [][]byte {
+		mu.Lock()
+		defer mu.Unlock()
+		return bodies
+	}
+}
+
+func TestSlackLink(t *testing.T) {
+	cases := []struct {
+		url, title, want string
+	}{
+		{"https://example.com/a", "Plain title", "<https://example.com/a|Plain title>"},
+		{"https://example.com/search?q=vpn&page=2", "Tom & Jerry", "<https://example.com/search?q=vpn&amp;page=2|Tom &amp; Jerry>"},
+		{"https://example.com/<script>", "<b>CVE</b> > 9.8", "<https://example.com/&lt;script&gt;|&lt;b&gt;CVE&lt;/b&gt; &gt; 9.8>"},
+		{"https://example.com/a|b", "Left | right", "<https://example.com/a%7Cb|Left - right>"},
+	}
+	for _, c := range cases {
+		if got := slackLink(c.url, c.title); got != c.want {
+			t.Errorf("slackLink(%q, %q) = %q, want %q", c.url, c.title, got, c.want)
+		}
+	}
+}
+
+func TestSlackNotifierEscapesSources(t *testing.T) {
+	srv, posts := capturePosts(t)
+	client, _ := recordingClient(1)
+	n, err := NewNotifier(types.NotifierConfig{Type: "slack", URL: srv.URL + "/services/T0/B0/x"}, NotifierOptions{Webhooks: client})
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc := Document{
+		Title:       "Content Ideas",
+		Brand:       "pwnspectrum",
+		GeneratedAt: time.Date(2026, 10, 18, 9, 0, 0, 0, time.UTC),
+		Content:     types.ContentIdeas{LinkedInPosts: []string{"Patch <now> & often"}},
+		Sources: []Source{
+			{Title: "Q&A: <VPN> | bypass", URL: "https://news.example.com/?id=1&ref=<feed>|x"},
+			{URL: "https://news.example.com/untitled?a=1&b=2"},
+		},
+	}
+	if err := n.Notify(context.Background(), Delivery{Doc: doc}); err != nil {
+		t.Fatal(err)
+	}
+
+	var text strings.Builder
+	for _, body := range posts() {
+		var msg slackMessage
+		if err := json.Unmarshal(body, &msg); err != nil {
+			t.Fatalf("posted body is not a Slack message: %v\n%s", err, body)
+		}
+		for _, block := range msg.Blocks {
+			if block.Text != nil {
+				text.WriteString(block.Text.Text + "\n")
+			}
+		}
+	}
+	for _, want := range []string{
+		"1. <https://news.example.com/?id=1&amp;ref=&lt;feed&gt;%7Cx|Q&amp;A: &lt;VPN&gt; - bypass>",
+		"2. <https://news.example.com/untitled?a=1&amp;b=2|https://news.example.com/untitled?a=1&amp;b=2>",
+		"Patch &lt;now&gt; &amp; often",
+	} {
+		if !strings.Contains(text.String(), want) {
+			t.Errorf("Slack message is missing %q:\n%s", want, text.String())
+		}
+	}
+}
@@ -0,0 +1,195 @@
+package utils
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/smtp"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/iamlucif3r/sarjan/internal/types"
+)
+
+// emailNotifier mails the bundle with Markdown and HTML bodies and the
+// rendered files attached. Port 465 uses implicit TLS; any other port
+// upgrades with STARTTLS when the server offers it.
+type emailNotifier struct {
+	notifierBase
+	smtp SMTPSettings
+	to   []string
+}
+
+func (n emailNotifier) Notify(ctx context.Context, d Delivery) error {
+	msg, err := buildEmail(n.smtp.From, n.to, d)
+	if err != nil {
+		return err
+	}
+	port := n.smtp.Port
+	if port == 0 {
+		port = 587
+	}
+	addr := net.JoinHostPort(n.smtp.Host, strconv.Itoa(port))
+
+	start := time.Now()
+	err = sendMail(ctx, addr, port == 465, n.smtp, n.to, msg)
+	if n.client.OnAttempt != nil {
+		attempt := types.DeliveryAttempt{Target: "smtp://" + addr, Attempt: 1, At: start, DurationMS: time.Since(start).Milliseconds()}
+		if err != nil {
+			attempt.Error = err.Error()
+		}
+		n.client.OnAttempt(attempt)
+	}
+	return err
+}
+
+func sendMail(ctx context.Context, addr string, implicitTLS bool, settings SMTPSettings, to []string, msg []byte) error {
+	dialer := &net.Dialer{Timeout: 30 * time.Second}
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return fmt.Errorf("failed to connect to %s: %w", addr, err)
+	}
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(2 * time.Minute)
+	}
+	_ = conn.SetDeadline(deadline)
+	tlsConfig := &tls.Config{ServerName: settings.Host}
+	if implicitTLS {
+		conn = tls.Client(conn, tlsConfig)
+	}
+
+	client, err := smtp.NewClient(conn, settings.Host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("failed to start SMTP session: %w", err)
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok && !implicitTLS {
+		if err := client.StartTLS(tlsConfig); err != nil {
+			return fmt.Errorf("STARTTLS failed: %w", err)
+		}
+	}
+	if settings.Username != "" {
+		if err := client.Auth(smtp.PlainAuth("", settings.Username, settings.Password, settings.Host)); err != nil {
+			return fmt.Errorf("SMTP authentication failed: %w", err)
+		}
+	}
+	if err := client.Mail(settings.From); err != nil {
+		return fmt.Errorf("SMTP MAIL FROM failed: %w", err)
+	}
+	for _, rcpt := range to {
+		if err := client.Rcpt(rcpt); err != nil {
+			return fmt.Errorf("SMTP RCPT TO %s failed: %w", rcpt, err)
+		}
+	}
+	w, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("SMTP DATA failed: %w", err)
+	}
+	if _, err := w.Write(msg); err != nil {
+		return fmt.Errorf("failed to write message: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("failed to send message: %w", err)
+	}
+	return client.Quit()
+}
+
+// buildEmail assembles a multipart/mixed message: a Markdown and HTML
+// alternative body followed by each file.
+func buildEmail(from string, to []string, d Delivery) ([]byte, error) {
+	var plain, rich bytes.Buffer
+	if err := (markdownRenderer{}).Render(&plain, d.Doc); err != nil {
+		return nil, fmt.Errorf("failed to render email text: %w", err)
+	}
+	if err := (htmlRenderer{}).Render(&rich, d.Doc); err != nil {
+		return nil, fmt.Errorf("failed to render email HTML: %w", err)
+	}
+
+	var msg bytes.Buffer
+	mixed := multipart.NewWriter(&msg)
+	fmt.Fprintf(&msg, "From: %s\r\nTo: %s\r\nSubject: %s\r\nDate: %s\r\nMIME-Version: 1.0\r\nContent-Type: multipart/mixed; boundary=%s\r\n\r\n",
+		from, strings.Join(to, ", "), mime.QEncoding.Encode("utf-8", d.Doc.heading()), time.Now().Format(time.RFC1123Z), mixed.Boundary())
+
+	var alt bytes.Buffer
+	alternative := multipart.NewWriter(&alt)
+	for _, body := range []struct {
+		contentType string
+		data        []byte
+	}{{"text/plain; charset=utf-8", plain.Bytes()}, {"text/html; charset=utf-8", rich.Bytes()}} {
+		part, err := alternative.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {body.contentType},
+			"Content-Transfer-Encoding": {"base64"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		writeBase64(part, body.data)
+	}
+	if err := alternative.Close(); err != nil {
+		return nil, err
+	}
+	part, err := mixed.CreatePart(textproto.MIMEHeader{"Content-Type": {"multipart/alternative; boundary=" + alternative.Boundary()}})
+	if err != nil {
+		return nil, err
+	}
+	part.Write(alt.Bytes())
+
+	for _, path := range d.Files {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		name := filepath.Base(path)
+		part, err := mixed.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {fileContentType(path)},
+			"Content-Transfer-Encoding": {"base64"},
+			"Content-Disposition":       {mime.FormatMediaType("attachment", map[string]string{"filename": name})},
+		})
+		if err != nil {
+			return nil, err
+		}
+		writeBase64(part, data)
+	}
+	if err := mixed.Close(); err != nil {
+		return nil, err
+	}
+	return msg.Bytes(), nil
+}
+
+// writeBase64 writes data base64-encoded in 76-character lines.
+func writeBase64(w io.Writer, data []byte) {
+	encoded := base64.StdEncoding.EncodeToString(data)
+	for len(encoded) > 76 {
+		w.Write([]byte(encoded[:76] + "\r\n"))
+		encoded = encoded[76:]
+	}
+	w.Write([]byte(encoded + "\r\n"))
+}
+
+// fileContentType prefers the content type of the renderer that writes
+// the file's extension.
+func fileContentType(path string) string {
+	ext := filepath.Ext(path)
+	for _, r := range builtinRenderers() {
+		if r.Extension() == ext {
+			return r.ContentType()
+		}
+	}
+	if t := mime.TypeByExtension(ext); t != "" {
+		return t
+	}
+	return "application/octet-stream"
+}
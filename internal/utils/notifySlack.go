@@ -0,0 +1,146 @@
+package utils
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// Slack rejects messages past these limits.
+const (
+	slackMaxBlocks      = 50
+	slackMaxSectionText = 3000
+	slackMaxHeaderText  = 150
+)
+
+// slackNotifier posts Block Kit messages to a Slack incoming webhook.
+// Incoming webhooks cannot upload files, so only the content is sent.
+type slackNotifier struct {
+	notifierBase
+	url string
+}
+
+type slackMessage struct {
+	Text   string       `json:"text"`
+	Blocks []slackBlock `json:"blocks"`
+}
+
+type slackBlock struct {
+	Type     string      `json:"type"`
+	Text     *slackText  `json:"text,omitempty"`
+	Elements []slackText `json:"elements,omitempty"`
+}
+
+type slackText struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+func (n slackNotifier) Notify(ctx context.Context, d Delivery) error {
+	for i, msg := range slackMessages(d.Doc) {
+		payload, err := json.Marshal(msg)
+		if err != nil {
+			return fmt.Errorf("failed to encode Slack message: %w", err)
+		}
+		if _, err := n.client.Post(ctx, n.url, http.Header{"Content-Type": {"application/json"}}, payload); err != nil {
+			return fmt.Errorf("failed to send Slack message %d: %w", i+1, err)
+		}
+	}
+	return nil
+}
+
+// slackMessages lays the bundle out as a header, one header block per
+// platform and one section per item, split into messages of at most 50
+// blocks.
+func slackMessages(doc Document) []slackMessage {
+	header := func(text string) slackBlock {
+		return slackBlock{Type: "header", Text: &slackText{Type: "plain_text", Text: truncateRunes(text, slackMaxHeaderText)}}
+	}
+	sections := func(text string) []slackBlock {
+		var out []slackBlock
+		for _, chunk := range splitRunes(text, slackMaxSectionText) {
+			out = append(out, slackBlock{Type: "section", Text: &slackText{Type: "mrkdwn", Text: chunk}})
+		}
+		return out
+	}
+
+	blocks := []slackBlock{
+		header(doc.heading()),
+		{Type: "context", Elements: []slackText{{Type: "mrkdwn", Text: "Generated " + doc.GeneratedAt.Format("02 Jan 2006 15:04")}}},
+	}
+	for _, section := range doc.sections() {
+		blocks = append(blocks, slackBlock{Type: "divider"}, header(section.Title))
+		for _, group := range section.Groups {
+			for _, item := range group.Items {
+				blocks = append(blocks, sections(slackItemText(group.Title, item))...)
+			}
+		}
+	}
+	if len(doc.Sources) > 0 {
+		var b strings.Builder
+		b.WriteString("*Sources*\n")
+		for i, source := range doc.Sources {
+			title := source.Title
+			if title == "" {
+				title = source.URL
+			}
+			fmt.Fprintf(&b, "%d. %s\n", i+1, slackLink(source.URL, title))
+		}
+		blocks = append(blocks, slackBlock{Type: "divider"})
+		blocks = append(blocks, sections(b.String())...)
+	}
+	if len(doc.Errors) > 0 {
+		platforms := make([]string, 0, len(doc.Errors))
+		for platform := range doc.Errors {
+			platforms = append(platforms, platform)
+		}
+		sort.Strings(platforms)
+		var b strings.Builder
+		b.WriteString("*Not generated*\n")
+		for _, platform := range platforms {
+			fmt.Fprintf(&b, "• *%s:* %s\n", platform, slackEscape(doc.Errors[platform]))
+		}
+		blocks = append(blocks, sections(b.String())...)
+	}
+
+	var messages []slackMessage
+	for len(blocks) > 0 {
+		n := min(len(blocks), slackMaxBlocks)
+		messages = append(messages, slackMessage{Text: doc.heading(), Blocks: blocks[:n]})
+		blocks = blocks[n:]
+	}
+	return messages
+}
+
+func slackItemText(group string, item docItem) string {
+	lines := []string{fmt.Sprintf("*%s · %s*", slackEscape(group), slackEscape(item.Label))}
+	for _, field := range item.Fields {
+		lines = append(lines, fmt.Sprintf("*%s:* %s", field.Name, slackEscape(field.Value)))
+	}
+	if item.Text != "" {
+		lines = append(lines, slackEscape(item.Text))
+	}
+	for _, entry := range item.List {
+		lines = append(lines, "• "+slackEscape(entry))
+	}
+	for _, f := range item.Flags {
+		lines = append(lines, fmt.Sprintf(":warning: *Needs edit:* `%s` %s", f.Path, slackEscape(f.Message)))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// slackLink writes a <url|title> link. Both halves are escaped; a | would
+// end the URL early, so it is percent-encoded there, and the title cannot
+// hold one at all.
+func slackLink(url, title string) string {
+	return "<" + slackEscape(strings.ReplaceAll(url, "|", "%7C")) + "|" + slackEscape(strings.ReplaceAll(title, "|", "-")) + ">"
+}
+
+// slackEscape escapes the three characters mrkdwn treats as control
+// characters.
+func slackEscape(s string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(s)
+}
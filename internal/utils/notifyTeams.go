@@ -0,0 +1,123 @@
+package utils
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// teamsMaxCardBytes keeps each card under the 28 KB Teams accepts per
+// webhook message, with room for the envelope.
+const teamsMaxCardBytes = 24 << 10
+
+// teamsNotifier posts Adaptive Cards to a Teams incoming webhook or
+// Workflows webhook URL: one card per platform, the first under the
+// bundle's heading, and a closing card with sources and errors. Teams
+// webhooks cannot take files.
+type teamsNotifier struct {
+	notifierBase
+	url string
+}
+
+type teamsElement map[string]any
+
+func (n teamsNotifier) Notify(ctx context.Context, d Delivery) error {
+	for i, card := range teamsCards(d.Doc) {
+		payload, err := json.Marshal(map[string]any{
+			"type": "message",
+			"attachments": []map[string]any{{
+				"contentType": "application/vnd.microsoft.card.adaptive",
+				"content": map[string]any{
+					"$schema": "http://adaptivecards.io/schemas/adaptive-card.json",
+					"type":    "AdaptiveCard",
+					"version": "1.4",
+					"msteams": map[string]any{"width": "Full"},
+					"body":    card,
+				},
+			}},
+		})
+		if err != nil {
+			return fmt.Errorf("failed to encode Teams card: %w", err)
+		}
+		if _, err := n.client.Post(ctx, n.url, http.Header{"Content-Type": {"application/json"}}, payload); err != nil {
+			return fmt.Errorf("failed to send Teams card %d: %w", i+1, err)
+		}
+	}
+	return nil
+}
+
+func teamsText(text string, style map[string]any) teamsElement {
+	el := teamsElement{"type": "TextBlock", "text": text, "wrap": true}
+	for k, v := range style {
+		el[k] = v
+	}
+	return el
+}
+
+// teamsCards lays the bundle out as card bodies, starting a new card for
+// each platform after the first and whenever the current one would grow
+// too large.
+func teamsCards(doc Document) [][]teamsElement {
+	cards := [][]teamsElement{{
+		teamsText(doc.heading(), map[string]any{"size": "Large", "weight": "Bolder"}),
+		teamsText("Generated "+doc.GeneratedAt.Format("02 Jan 2006 15:04"), map[string]any{"isSubtle": true, "spacing": "None"}),
+	}}
+	size := 0
+	add := func(el teamsElement, newCard bool) {
+		encoded, _ := json.Marshal(el)
+		if (newCard && size > 0) || size+len(encoded) > teamsMaxCardBytes {
+			cards = append(cards, nil)
+			size = 0
+		}
+		cards[len(cards)-1] = append(cards[len(cards)-1], el)
+		size += len(encoded)
+	}
+
+	for _, section := range doc.sections() {
+		add(teamsText(section.Title, map[string]any{"size": "Medium", "weight": "Bolder", "color": "Accent"}), true)
+		for _, group := range section.Groups {
+			for _, item := range group.Items {
+				items := []teamsElement{teamsText(group.Title+" · "+item.Label, map[string]any{"weight": "Bolder"})}
+				for _, field := range item.Fields {
+					items = append(items, teamsText(fmt.Sprintf("**%s:** %s", field.Name, field.Value), map[string]any{"spacing": "Small"}))
+				}
+				if item.Text != "" {
+					items = append(items, teamsText(item.Text, map[string]any{"spacing": "Small"}))
+				}
+				if len(item.List) > 0 {
+					items = append(items, teamsText("- "+strings.Join(item.List, "\n- "), map[string]any{"spacing": "Small"}))
+				}
+				for _, f := range item.Flags {
+					items = append(items, teamsText(fmt.Sprintf("⚠️ Needs edit: %s %s", f.Path, f.Message), map[string]any{"color": "Attention", "spacing": "Small"}))
+				}
+				add(teamsElement{"type": "Container", "separator": true, "items": items}, false)
+			}
+		}
+	}
+
+	if len(doc.Sources) > 0 {
+		add(teamsText("Sources", map[string]any{"size": "Medium", "weight": "Bolder"}), true)
+		for i, source := range doc.Sources {
+			title := source.Title
+			if title == "" {
+				title = source.URL
+			}
+			add(teamsText(fmt.Sprintf("%d. [%s](%s)", i+1, strings.ReplaceAll(title, "]", ")"), source.URL), map[string]any{"spacing": "None"}), false)
+		}
+	}
+	if len(doc.Errors) > 0 {
+		platforms := make([]string, 0, len(doc.Errors))
+		for platform := range doc.Errors {
+			platforms = append(platforms, platform)
+		}
+		sort.Strings(platforms)
+		add(teamsText("Not generated", map[string]any{"size": "Medium", "weight": "Bolder", "color": "Attention"}), len(doc.Sources) == 0)
+		for _, platform := range platforms {
+			add(teamsText(fmt.Sprintf("**%s:** %s", platform, doc.Errors[platform]), map[string]any{"spacing": "None"}), false)
+		}
+	}
+	return cards
+}
@@ -0,0 +1,153 @@
+package utils
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"html"
+	"mime/multipart"
+	"net/http"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// telegramMaxText is the Bot API's limit for one message.
+const telegramMaxText = 4096
+
+// telegramNotifier sends the bundle through the Telegram Bot API as HTML
+// messages, packed up to the length limit, then each file as a document.
+type telegramNotifier struct {
+	notifierBase
+	apiURL string
+	token  string
+	chatID string
+}
+
+func (n telegramNotifier) Notify(ctx context.Context, d Delivery) error {
+	for i, text := range telegramMessages(d.Doc) {
+		payload, err := json.Marshal(map[string]any{
+			"chat_id":              n.chatID,
+			"text":                 text,
+			"parse_mode":           "HTML",
+			"link_preview_options": map[string]bool{"is_disabled": true},
+		})
+		if err != nil {
+			return fmt.Errorf("failed to encode Telegram message: %w", err)
+		}
+		if _, err := n.client.Post(ctx, n.method("sendMessage"), http.Header{"Content-Type": {"application/json"}}, payload); err != nil {
+			return fmt.Errorf("failed to send Telegram message %d: %w", i+1, err)
+		}
+	}
+
+	for _, path := range d.Files {
+		var body bytes.Buffer
+		writer := multipart.NewWriter(&body)
+		_ = writer.WriteField("chat_id", n.chatID)
+		if err := attachFile(writer, "document", path); err != nil {
+			return err
+		}
+		if err := writer.Close(); err != nil {
+			return fmt.Errorf("failed to close multipart writer: %w", err)
+		}
+		if _, err := n.client.Post(ctx, n.method("sendDocument"), http.Header{"Content-Type": {writer.FormDataContentType()}}, body.Bytes()); err != nil {
+			return fmt.Errorf("failed to send %s to Telegram: %w", path, err)
+		}
+	}
+	return nil
+}
+
+func (n telegramNotifier) method(name string) string {
+	return n.apiURL + "/bot" + n.token + "/" + name
+}
+
+// telegramMessages renders the bundle as HTML blocks, one per item, and
+// packs them into as few messages as fit. An item too long for one
+// message is split before it is escaped so no tag is cut in half.
+func telegramMessages(doc Document) []string {
+	var blocks []string
+	addItem := func(title, body string) {
+		heading := "<b>" + html.EscapeString(title) + "</b>\n"
+		room := telegramMaxText - utf8.RuneCountInString(heading)
+		// Escaping can grow the text, so leave it headroom.
+		for _, chunk := range splitRunes(body, room*2/3) {
+			blocks = append(blocks, heading+html.EscapeString(chunk))
+		}
+	}
+
+	blocks = append(blocks, fmt.Sprintf("<b>%s</b>\n<i>Generated %s</i>", html.EscapeString(doc.heading()), doc.GeneratedAt.Format("02 Jan 2006 15:04")))
+	for _, section := range doc.sections() {
+		blocks = append(blocks, "<b>━━ "+html.EscapeString(section.Title)+" ━━</b>")
+		for _, group := range section.Groups {
+			for _, item := range group.Items {
+				addItem(group.Title+" · "+item.Label, plainItemText(item))
+			}
+		}
+	}
+	if len(doc.Sources) > 0 {
+		var b strings.Builder
+		b.WriteString("<b>Sources</b>")
+		for i, source := range doc.Sources {
+			title := source.Title
+			if title == "" {
+				title = source.URL
+			}
+			line := fmt.Sprintf("\n%d. <a href=\"%s\">%s</a>", i+1, html.EscapeString(source.URL), html.EscapeString(title))
+			if utf8.RuneCountInString(b.String()+line) > telegramMaxText {
+				blocks = append(blocks, b.String())
+				b.Reset()
+			}
+			b.WriteString(line)
+		}
+		blocks = append(blocks, b.String())
+	}
+	if len(doc.Errors) > 0 {
+		platforms := make([]string, 0, len(doc.Errors))
+		for platform := range doc.Errors {
+			platforms = append(platforms, platform)
+		}
+		sort.Strings(platforms)
+		var lines []string
+		for _, platform := range platforms {
+			lines = append(lines, platform+": "+doc.Errors[platform])
+		}
+		addItem("Not generated", strings.Join(lines, "\n"))
+	}
+
+	var messages []string
+	var current string
+	for _, block := range blocks {
+		if current != "" && utf8.RuneCountInString(current)+2+utf8.RuneCountInString(block) > telegramMaxText {
+			messages = append(messages, current)
+			current = ""
+		}
+		if current != "" {
+			current += "\n\n"
+		}
+		current += block
+	}
+	if current != "" {
+		messages = append(messages, current)
+	}
+	return messages
+}
+
+// plainItemText is one item as unformatted text, for sinks that escape or
+// mark it up themselves.
+func plainItemText(item docItem) string {
+	var lines []string
+	for _, field := range item.Fields {
+		lines = append(lines, field.Name+": "+field.Value)
+	}
+	if item.Text != "" {
+		lines = append(lines, item.Text)
+	}
+	for _, entry := range item.List {
+		lines = append(lines, "• "+entry)
+	}
+	for _, f := range item.Flags {
+		lines = append(lines, "⚠️ Needs edit: "+f.Path+" "+f.Message)
+	}
+	return strings.Join(lines, "\n")
+}
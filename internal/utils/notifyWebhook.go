@@ -0,0 +1,55 @@
+package utils
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+)
+
+// webhookNotifier POSTs the bundle as JSON to any URL, with optional extra
+// headers such as an Authorization token. Files are described, not sent.
+type webhookNotifier struct {
+	notifierBase
+	url     string
+	headers map[string]string
+}
+
+type webhookPayload struct {
+	Event    string        `json:"event"`
+	Document Document      `json:"document"`
+	Files    []webhookFile `json:"files,omitempty"`
+}
+
+type webhookFile struct {
+	Name        string `json:"name"`
+	ContentType string `json:"content_type"`
+	Size        int64  `json:"size"`
+}
+
+func (n webhookNotifier) Notify(ctx context.Context, d Delivery) error {
+	payload := webhookPayload{Event: "bundle.ready", Document: d.Doc}
+	for _, path := range d.Files {
+		info, err := os.Stat(path)
+		if err != nil {
+			return fmt.Errorf("failed to stat %s: %w", path, err)
+		}
+		payload.Files = append(payload.Files, webhookFile{Name: filepath.Base(path), ContentType: fileContentType(path), Size: info.Size()})
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to encode webhook payload: %w", err)
+	}
+
+	header := http.Header{}
+	for k, v := range n.headers {
+		header.Set(k, v)
+	}
+	header.Set("Content-Type", "application/json")
+	if _, err := n.client.Post(ctx, n.url, header, body); err != nil {
+		return fmt.Errorf("failed to send webhook: %w", err)
+	}
+	return nil
+}
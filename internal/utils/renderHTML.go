@@ -0,0 +1,71 @@
+package utils
+
+import (
+	"html/template"
+	"io"
+)
+
+// htmlRenderer writes a single self-contained page with inline styles, so
+// the file can be opened or forwarded without anything else alongside it.
+type htmlRenderer struct{}
+
+func (htmlRenderer) Format() string      { return "html" }
+func (htmlRenderer) Extension() string   { return ".html" }
+func (htmlRenderer) ContentType() string { return "text/html; charset=utf-8" }
+
+func (htmlRenderer) Render(w io.Writer, doc Document) error {
+	return htmlTemplate.Execute(w, struct {
+		Heading   string
+		Generated string
+		Sections  []docSection
+		Sources   []Source
+		Errors    map[string]string
+	}{doc.heading(), doc.GeneratedAt.Format("02 Jan 2006 15:04"), doc.sections(), doc.Sources, doc.Errors})
+}
+
+var htmlTemplate = template.Must(template.New("bundle").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Heading}}</title>
+<style>
+body { font-family: -apple-system, "Segoe UI", Roboto, "Noto Sans", sans-serif, "Apple Color Emoji", "Segoe UI Emoji"; max-width: 52rem; margin: 2rem auto; padding: 0 1rem; color: #1f2328; line-height: 1.5; }
+h1 { margin-bottom: 0; }
+.generated { color: #656d76; margin-top: .25rem; }
+h2 { border-bottom: 1px solid #d0d7de; padding-bottom: .3rem; margin-top: 2.5rem; }
+.item { border: 1px solid #d0d7de; border-radius: 6px; padding: .75rem 1rem; margin: .75rem 0; }
+.label { font-weight: 600; color: #656d76; font-size: .85rem; text-transform: uppercase; letter-spacing: .04em; }
+.text { white-space: pre-wrap; margin: .5rem 0; }
+.flag { background: #fff1f0; border-left: 3px solid #cf222e; padding: .25rem .5rem; margin-top: .5rem; font-size: .9rem; }
+.errors { color: #cf222e; }
+</style>
+</head>
+<body>
+<h1>{{.Heading}}</h1>
+<p class="generated">Generated {{.Generated}}</p>
+{{range .Sections}}
+<section id="{{.Platform}}">
+<h2>{{.Title}}</h2>
+{{range .Groups}}<h3>{{.Title}}</h3>
+{{range .Items}}<div class="item">
+<div class="label">{{.Label}}</div>
+{{range .Fields}}<p><strong>{{.Name}}:</strong> {{.Value}}</p>
+{{end}}{{with .Text}}<p class="text">{{.}}</p>
+{{end}}{{with .List}}<ul>{{range .}}<li class="text">{{.}}</li>{{end}}</ul>
+{{end}}{{range .Flags}}<div class="flag">⚠️ Needs edit: <code>{{.Path}}</code> {{.Message}}</div>
+{{end}}</div>
+{{end}}{{end}}</section>
+{{end}}{{with .Sources}}
+<section id="sources">
+<h2>Sources</h2>
+<ol>{{range .}}<li><a href="{{.URL}}">{{if .Title}}{{.Title}}{{else}}{{.URL}}{{end}}</a></li>{{end}}</ol>
+</section>
+{{end}}{{with .Errors}}
+<section class="errors">
+<h2>Not generated</h2>
+<ul>{{range $platform, $err := .}}<li><strong>{{$platform}}:</strong> {{$err}}</li>{{end}}</ul>
+</section>
+{{end}}</body>
+</html>
+`))
@@ -0,0 +1,20 @@
+package utils
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// jsonRenderer writes the Document as indented JSON for other tools.
+type jsonRenderer struct{}
+
+func (jsonRenderer) Format() string      { return "json" }
+func (jsonRenderer) Extension() string   { return ".json" }
+func (jsonRenderer) ContentType() string { return "application/json" }
+
+func (jsonRenderer) Render(w io.Writer, doc Document) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.SetEscapeHTML(false)
+	return enc.Encode(doc)
+}
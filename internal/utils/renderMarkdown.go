@@ -0,0 +1,71 @@
+package utils
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// markdownRenderer writes plain Markdown with every post as its own
+// paragraph, so writers can copy a tweet without cleaning it up.
+type markdownRenderer struct{}
+
+func (markdownRenderer) Format() string      { return "markdown" }
+func (markdownRenderer) Extension() string   { return ".md" }
+func (markdownRenderer) ContentType() string { return "text/markdown; charset=utf-8" }
+
+func (markdownRenderer) Render(w io.Writer, doc Document) error {
+	b := bufio.NewWriter(w)
+	fmt.Fprintf(b, "# %s\n\n_Generated %s_\n\n", doc.heading(), doc.GeneratedAt.Format("02 Jan 2006 15:04"))
+
+	for _, section := range doc.sections() {
+		fmt.Fprintf(b, "## %s\n\n", section.Title)
+		for _, group := range section.Groups {
+			fmt.Fprintf(b, "### %s\n\n", group.Title)
+			for _, item := range group.Items {
+				fmt.Fprintf(b, "#### %s\n\n", item.Label)
+				for _, field := range item.Fields {
+					fmt.Fprintf(b, "**%s:** %s\n\n", field.Name, field.Value)
+				}
+				if item.Text != "" {
+					fmt.Fprintf(b, "%s\n\n", item.Text)
+				}
+				for _, line := range item.List {
+					fmt.Fprintf(b, "- %s\n", line)
+				}
+				if len(item.List) > 0 {
+					b.WriteString("\n")
+				}
+				for _, f := range item.Flags {
+					fmt.Fprintf(b, "> ⚠️ **Needs edit:** `%s` %s\n\n", f.Path, f.Message)
+				}
+			}
+		}
+	}
+
+	if len(doc.Sources) > 0 {
+		b.WriteString("## Sources\n\n")
+		for i, source := range doc.Sources {
+			title := source.Title
+			if title == "" {
+				title = source.URL
+			}
+			fmt.Fprintf(b, "%d. [%s](%s)\n", i+1, title, source.URL)
+		}
+		b.WriteString("\n")
+	}
+
+	if len(doc.Errors) > 0 {
+		b.WriteString("## Not generated\n\n")
+		platforms := make([]string, 0, len(doc.Errors))
+		for platform := range doc.Errors {
+			platforms = append(platforms, platform)
+		}
+		sort.Strings(platforms)
+		for _, platform := range platforms {
+			fmt.Fprintf(b, "- **%s:** %s\n", platform, doc.Errors[platform])
+		}
+	}
+	return b.Flush()
+}
@@ -0,0 +1,448 @@
+package utils
+
+import (
+	"bytes"
+	_ "embed"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/signintech/gopdf"
+)
+
+//go:embed fonts/DejaVuSansCondensed.ttf
+var dejaVuRegular []byte
+
+//go:embed fonts/DejaVuSansCondensed-Bold.ttf
+var dejaVuBold []byte
+
+const (
+	pdfFont      = "DejaVu"
+	pdfFontBold  = "DejaVuBold"
+	pdfEmojiFont = "Emoji"
+	pdfMargin    = 50.0
+	pdfFooterY   = 30.0
+)
+
+// pdfRenderer lays the bundle out on A4 pages: a cover, a table of
+// contents, one section per platform and the cited sources. Text is set in
+// the embedded DejaVu font so non-ASCII survives; emoji use emojiFont when
+// one is configured and a text placeholder otherwise.
+type pdfRenderer struct {
+	emojiFont []byte
+}
+
+func (pdfRenderer) Format() string      { return "pdf" }
+func (pdfRenderer) Extension() string   { return ".pdf" }
+func (pdfRenderer) ContentType() string { return "application/pdf" }
+
+func (r pdfRenderer) Render(w io.Writer, doc Document) error {
+	// Section page numbers are only known once the body is laid out, so
+	// lay it out once to learn them and again to print them in the TOC.
+	pages := map[string]int{}
+	var scratch bytes.Buffer
+	if err := r.render(&scratch, doc, pages); err != nil {
+		return err
+	}
+	return r.render(w, doc, pages)
+}
+
+func (r pdfRenderer) render(w io.Writer, doc Document, pages map[string]int) error {
+	gp := &gopdf.GoPdf{}
+	gp.Start(gopdf.Config{PageSize: *gopdf.PageSizeA4})
+	gp.SetInfo(gopdf.PdfInfo{Title: doc.heading(), Creator: "sarjan", CreationDate: doc.GeneratedAt})
+	l := &pdfLayout{gp: gp, heading: doc.heading(), emoji: r.emojiFont != nil}
+	l.check(gp.AddTTFFontData(pdfFont, dejaVuRegular))
+	l.check(gp.AddTTFFontData(pdfFontBold, dejaVuBold))
+	if r.emojiFont != nil {
+		l.check(gp.AddTTFFontData(pdfEmojiFont, r.emojiFont))
+	}
+	if l.err != nil {
+		return l.err
+	}
+	sections := doc.sections()
+
+	// Cover.
+	l.newPage()
+	gp.SetY(280)
+	l.centered("B", 26, doc.heading())
+	gp.SetTextColor(90, 90, 90)
+	l.centered("", 12, "Generated "+doc.GeneratedAt.Format("02 Jan 2006 15:04"))
+	gp.SetY(gp.GetY() + 20)
+	var summary []string
+	for _, section := range sections {
+		n := 0
+		for _, group := range section.Groups {
+			n += len(group.Items)
+		}
+		summary = append(summary, fmt.Sprintf("%s: %d", section.Title, n))
+	}
+	if len(doc.Sources) > 0 {
+		summary = append(summary, fmt.Sprintf("Sources: %d", len(doc.Sources)))
+	}
+	if len(doc.Flags) > 0 {
+		summary = append(summary, fmt.Sprintf("Needs edit: %d", len(doc.Flags)))
+	}
+	l.centered("", 11, strings.Join(summary, "   ·   "))
+	gp.SetTextColor(0, 0, 0)
+
+	// Table of contents.
+	l.newPage()
+	l.paragraph("B", 20, "Contents", nil)
+	gp.SetY(gp.GetY() + 10)
+	tocEntry := func(key, title string) {
+		l.tocLine(title, pages[key], key)
+	}
+	for _, section := range sections {
+		tocEntry(section.Platform, section.Title)
+	}
+	if len(doc.Sources) > 0 {
+		tocEntry("sources", "Sources")
+	}
+	if len(doc.Errors) > 0 {
+		tocEntry("errors", "Not generated")
+	}
+	startSection := func(key, title string) {
+		l.newPage()
+		pages[key] = l.page
+		gp.SetAnchor(key)
+		l.paragraph("B", 20, title, nil)
+		gp.SetY(gp.GetY() + 8)
+	}
+
+	for _, section := range sections {
+		startSection(section.Platform, section.Title)
+		for _, group := range section.Groups {
+			l.ensure(60)
+			l.paragraph("B", 15, group.Title, nil)
+			gp.SetY(gp.GetY() + 6)
+
+			for _, item := range group.Items {
+				l.ensure(40)
+				l.paragraph("B", 12, item.Label, nil)
+				for _, field := range item.Fields {
+					l.paragraph("", 11, field.Name+": "+field.Value, nil)
+				}
+				if item.Text != "" {
+					l.paragraph("", 11, item.Text, nil)
+				}
+				for _, entry := range item.List {
+					l.paragraph("", 11, "• "+entry, nil)
+				}
+				for _, f := range item.Flags {
+					gp.SetTextColor(200, 0, 0)
+					l.paragraph("", 9, "⚠ Needs edit: "+f.Path+" "+f.Message, nil)
+					gp.SetTextColor(0, 0, 0)
+				}
+				gp.SetY(gp.GetY() + 10)
+			}
+		}
+	}
+
+	if len(doc.Sources) > 0 {
+		startSection("sources", "Sources")
+		for i, source := range doc.Sources {
+			title := source.Title
+			if title == "" {
+				title = source.URL
+			}
+			var link func(x, y, w, h float64)
+			if source.URL != "" {
+				gp.SetTextColor(20, 80, 180)
+				link = func(x, y, w, h float64) { gp.AddExternalLink(source.URL, x, y, w, h) }
+			}
+			l.paragraph("", 11, fmt.Sprintf("[%d] %s", i+1, title), link)
+			gp.SetTextColor(0, 0, 0)
+			gp.SetY(gp.GetY() + 4)
+		}
+	}
+
+	if len(doc.Errors) > 0 {
+		startSection("errors", "Not generated")
+		platforms := make([]string, 0, len(doc.Errors))
+		for platform := range doc.Errors {
+			platforms = append(platforms, platform)
+		}
+		sort.Strings(platforms)
+		for _, platform := range platforms {
+			l.paragraph("", 11, platform+": "+doc.Errors[platform], nil)
+		}
+	}
+
+	l.footer()
+	if l.err != nil {
+		return l.err
+	}
+	return gp.Write(w)
+}
+
+// pdfLayout does the flowing gopdf leaves to the caller: word wrapping
+// across font changes, page breaks and footers. The first error is kept
+// and the rest of the layout becomes a no-op.
+type pdfLayout struct {
+	gp      *gopdf.GoPdf
+	heading string
+	emoji   bool
+	page    int
+	err     error
+}
+
+func (l *pdfLayout) check(err error) {
+	if l.err == nil && err != nil {
+		l.err = err
+	}
+}
+
+func (l *pdfLayout) newPage() {
+	if l.page > 0 {
+		l.footer()
+	}
+	l.gp.AddPage()
+	l.page++
+	l.gp.SetXY(pdfMargin, pdfMargin)
+}
+
+func (l *pdfLayout) footer() {
+	if l.page <= 1 {
+		return
+	}
+	text := fmt.Sprintf("%s · page %d", l.prepare(l.heading), l.page)
+	l.gp.SetTextColor(120, 120, 120)
+	width := l.width(text, "", 8)
+	l.gp.SetXY((gopdf.PageSizeA4.W-width)/2, gopdf.PageSizeA4.H-pdfFooterY)
+	l.draw(text, "", 8)
+	l.gp.SetTextColor(0, 0, 0)
+}
+
+// ensure starts a new page unless h points fit above the bottom margin.
+func (l *pdfLayout) ensure(h float64) {
+	if l.gp.GetY()+h > gopdf.PageSizeA4.H-pdfMargin {
+		l.newPage()
+	}
+}
+
+// setFont selects the text or emoji font for a run.
+func (l *pdfLayout) setFont(style string, size float64, emoji bool) {
+	family := pdfFont
+	switch {
+	case emoji && l.emoji:
+		family = pdfEmojiFont
+	case style == "B":
+		family = pdfFontBold
+	}
+	l.check(l.gp.SetFont(family, "", size))
+}
+
+// prepare cleans text and, without an emoji font, swaps emoji for
+// placeholders so everything left is drawable with DejaVu.
+func (l *pdfLayout) prepare(s string) string {
+	s = cleanPDFText(s)
+	if l.emoji {
+		return s
+	}
+	var b strings.Builder
+	for _, run := range splitEmoji(s) {
+		if run.emoji {
+			b.WriteString(emojiPlaceholders(run.text))
+		} else {
+			b.WriteString(run.text)
+		}
+	}
+	return b.String()
+}
+
+func (l *pdfLayout) width(s, style string, size float64) float64 {
+	total := 0.0
+	for _, run := range splitEmoji(s) {
+		l.setFont(style, size, run.emoji)
+		w, err := l.gp.MeasureTextWidth(run.text)
+		l.check(err)
+		total += w
+	}
+	return total
+}
+
+// draw writes one prepared line at the current position.
+func (l *pdfLayout) draw(s, style string, size float64) {
+	x, y := l.gp.GetX(), l.gp.GetY()
+	for _, run := range splitEmoji(s) {
+		l.setFont(style, size, run.emoji)
+		w, err := l.gp.MeasureTextWidth(run.text)
+		l.check(err)
+		l.gp.SetXY(x, y)
+		l.check(l.gp.Cell(nil, run.text))
+		x += w
+	}
+	l.gp.SetXY(x, y)
+}
+
+// paragraph wraps text to the margins, honouring its line breaks. link,
+// when set, is called with the box of every line drawn.
+func (l *pdfLayout) paragraph(style string, size float64, text string, link func(x, y, w, h float64)) {
+	if l.err != nil {
+		return
+	}
+	lineHeight := size * 1.45
+	maxWidth := gopdf.PageSizeA4.W - 2*pdfMargin
+	for _, hard := range strings.Split(l.prepare(text), "\n") {
+		if strings.TrimSpace(hard) == "" {
+			l.gp.SetY(l.gp.GetY() + lineHeight/2)
+			continue
+		}
+		for _, line := range l.wrap(hard, style, size, maxWidth) {
+			l.ensure(lineHeight)
+			y := l.gp.GetY()
+			l.gp.SetX(pdfMargin)
+			l.draw(line, style, size)
+			if link != nil {
+				link(pdfMargin, y, l.gp.GetX()-pdfMargin, lineHeight)
+			}
+			l.gp.SetXY(pdfMargin, y+lineHeight)
+		}
+	}
+}
+
+// wrap breaks s into lines no wider than maxWidth, splitting words that
+// are wider than a line on their own.
+func (l *pdfLayout) wrap(s, style string, size, maxWidth float64) []string {
+	var lines []string
+	current := ""
+	for _, word := range strings.Fields(s) {
+		candidate := word
+		if current != "" {
+			candidate = current + " " + word
+		}
+		if l.width(candidate, style, size) <= maxWidth {
+			current = candidate
+			continue
+		}
+		if current != "" {
+			lines = append(lines, current)
+			current = ""
+		}
+		for l.width(word, style, size) > maxWidth {
+			runes := []rune(word)
+			n := len(runes) - 1
+			for n > 1 && l.width(string(runes[:n]), style, size) > maxWidth {
+				n--
+			}
+			lines = append(lines, string(runes[:n]))
+			word = string(runes[n:])
+		}
+		current = word
+	}
+	if current != "" {
+		lines = append(lines, current)
+	}
+	return lines
+}
+
+func (l *pdfLayout) centered(style string, size float64, text string) {
+	text = l.prepare(text)
+	width := l.width(text, style, size)
+	if width > gopdf.PageSizeA4.W-2*pdfMargin {
+		l.paragraph(style, size, text, nil)
+		return
+	}
+	y := l.gp.GetY()
+	l.gp.SetX((gopdf.PageSizeA4.W - width) / 2)
+	l.draw(text, style, size)
+	l.gp.SetXY(pdfMargin, y+size*1.6)
+}
+
+// tocLine writes a contents entry linked to the section's anchor, with its
+// page number right-aligned once it is known.
+func (l *pdfLayout) tocLine(title string, page int, anchor string) {
+	const size, lineHeight = 13.0, 22.0
+	y := l.gp.GetY()
+	l.gp.SetX(pdfMargin)
+	l.draw(l.prepare(title), "", size)
+	if page > 0 {
+		number := fmt.Sprint(page)
+		l.gp.SetX(gopdf.PageSizeA4.W - pdfMargin - l.width(number, "", size))
+		l.draw(number, "", size)
+	}
+	l.gp.AddInternalLink(anchor, pdfMargin, y, gopdf.PageSizeA4.W-2*pdfMargin, lineHeight)
+	l.gp.SetXY(pdfMargin, y+lineHeight)
+}
+
+// cleanPDFText repairs UTF-8 that was decoded as Windows-1252 somewhere
+// upstream and drops control characters other than newlines.
+func cleanPDFText(s string) string {
+	s = strings.ReplaceAll(s, "â€™", "’")
+	s = strings.ReplaceAll(s, "â€“", "–")
+	s = strings.ReplaceAll(s, "â€œ", "“")
+	s = strings.ReplaceAll(s, "â€", "”")
+	s = strings.ReplaceAll(s, "â€˜", "‘")
+	s = strings.ReplaceAll(s, "â€¦", "…")
+	return strings.Map(func(r rune) rune {
+		if r == '\t' {
+			return ' '
+		}
+		if r != '\n' && unicode.IsControl(r) {
+			return -1
+		}
+		return r
+	}, s)
+}
+
+type textRun struct {
+	text  string
+	emoji bool
+}
+
+// isEmoji reports whether r is outside what DejaVu covers and belongs to
+// the emoji font. Joiners and variation selectors travel with the emoji
+// they modify.
+func isEmoji(r rune) bool {
+	return r >= 0x1F000 || r == 0x200D || r == 0xFE0F || r == 0x2705 || r == 0x274C || r == 0x2728
+}
+
+func splitEmoji(s string) []textRun {
+	var runs []textRun
+	var b strings.Builder
+	current := false
+	for _, r := range s {
+		e := isEmoji(r)
+		if e != current && b.Len() > 0 {
+			runs = append(runs, textRun{b.String(), current})
+			b.Reset()
+		}
+		current = e
+		b.WriteRune(r)
+	}
+	if b.Len() > 0 {
+		runs = append(runs, textRun{b.String(), current})
+	}
+	return runs
+}
+
+var emojiNames = map[rune]string{
+	'🔥': "fire", '🚀': "rocket", '💣': "bomb", '🔪': "knife", '🧠': "brain",
+	'🧨': "firecracker", '💀': "skull", '👀': "eyes", '🔒': "lock", '🔓': "unlock",
+	'🛡': "shield", '🐛': "bug", '💥': "boom", '🎯': "target", '📦': "package",
+	'🧵': "thread", '👇': "down", '👉': "right", '🤖': "robot", '😂': "joy",
+	'😈': "imp", '🕵': "detective", '🔍': "search", '📈': "chart", '💡': "idea",
+	'🚨': "alert", '✅': "check", '❌': "x", '✨': "sparkles", '🤯': "mind blown",
+	'🟥': "red", '🟦': "blue", '🟩': "green", '🟪': "purple", '💻': "laptop",
+	'😱': "scream", '🙃': "upside down", '🤡': "clown", '🧑': "person", '👨': "man",
+}
+
+// emojiPlaceholders replaces each emoji in s with its :name:, or a box for
+// ones without a name, dropping joiners and modifiers.
+func emojiPlaceholders(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if r == 0x200D || r == 0xFE0F || (r >= 0x1F3FB && r <= 0x1F3FF) {
+			continue
+		}
+		if name, ok := emojiNames[r]; ok {
+			b.WriteString(":" + name + ":")
+		} else {
+			b.WriteRune('□')
+		}
+	}
+	return b.String()
+}
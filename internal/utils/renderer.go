@@ -0,0 +1,224 @@
+package utils
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/iamlucif3r/sarjan/internal/types"
+)
+
+// Document is everything a renderer needs to produce one bundle's output.
+type Document struct {
+	Title       string              `json:"title"`
+	Brand       string              `json:"brand"`
+	GeneratedAt time.Time           `json:"generated_at"`
+	Content     types.ContentIdeas  `json:"content"`
+	Flags       []types.ContentFlag `json:"flags,omitempty"`
+	Errors      map[string]string   `json:"errors,omitempty"`
+	// Sources are the articles the content was generated from.
+	Sources []Source `json:"sources,omitempty"`
+}
+
+// Source is one cited article.
+type Source struct {
+	Title string `json:"title"`
+	URL   string `json:"url"`
+}
+
+// Renderer writes a Document in one output format.
+type Renderer interface {
+	// Format is the name callers select the renderer by, e.g. "pdf".
+	Format() string
+	Extension() string
+	ContentType() string
+	Render(w io.Writer, doc Document) error
+}
+
+// Renderers maps format names to the renderer for each.
+type Renderers map[string]Renderer
+
+// NewRenderers returns the built-in renderers. emojiFont, when not empty,
+// is a TTF used for emoji in PDF output; otherwise the PDF keeps text
+// placeholders.
+func NewRenderers(emojiFont string) (Renderers, error) {
+	rs := builtinRenderers()
+	if emojiFont != "" {
+		font, err := os.ReadFile(emojiFont)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load PDF emoji font: %w", err)
+		}
+		rs[pdfRenderer{}.Format()] = pdfRenderer{emojiFont: font}
+	}
+	return rs, nil
+}
+
+func builtinRenderers() Renderers {
+	rs := Renderers{}
+	for _, r := range []Renderer{pdfRenderer{}, markdownRenderer{}, htmlRenderer{}, jsonRenderer{}} {
+		rs[r.Format()] = r
+	}
+	return rs
+}
+
+// Get returns the renderer for a format name.
+func (rs Renderers) Get(format string) (Renderer, error) {
+	r, ok := rs[strings.ToLower(strings.TrimSpace(format))]
+	if !ok {
+		return nil, fmt.Errorf("unknown output format %q (have %s)", format, strings.Join(rs.Formats(), ", "))
+	}
+	return r, nil
+}
+
+// Formats lists the format names.
+func (rs Renderers) Formats() []string {
+	formats := make([]string, 0, len(rs))
+	for name := range rs {
+		formats = append(formats, name)
+	}
+	sort.Strings(formats)
+	return formats
+}
+
+// RenderToFile renders doc to path, creating its directory.
+func RenderToFile(r Renderer, doc Document, path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create output directory: %w", err)
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", path, err)
+	}
+	if err := r.Render(f, doc); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to render %s: %w", r.Format(), err)
+	}
+	return f.Close()
+}
+
+// docSection is one platform's content laid out for the text renderers.
+type docSection struct {
+	Platform string
+	Title    string
+	Groups   []docGroup
+}
+
+type docGroup struct {
+	Title string
+	Items []docItem
+}
+
+// docItem is one generated piece. Text is the copyable body, Fields are
+// labelled extras and List holds bullet points or thread tweets.
+type docItem struct {
+	Label  string
+	Text   string
+	Fields []docField
+	List   []string
+	Flags  []types.ContentFlag
+}
+
+type docField struct {
+	Name  string
+	Value string
+}
+
+var platformTitles = map[string]string{
+	"youtube":   "YouTube",
+	"twitter":   "Twitter / X",
+	"linkedin":  "LinkedIn",
+	"instagram": "Instagram",
+}
+
+// sections lays the bundle out by platform, in types.Platforms order,
+// skipping platforms with no content.
+func (doc Document) sections() []docSection {
+	flagsFor := func(field string, index int) []types.ContentFlag {
+		var out []types.ContentFlag
+		for _, f := range doc.Flags {
+			if f.Field == field && f.Index == index {
+				out = append(out, f)
+			}
+		}
+		return out
+	}
+	c := doc.Content
+	groups := map[string][]docGroup{}
+	add := func(platform, title string, items []docItem) {
+		if len(items) > 0 {
+			groups[platform] = append(groups[platform], docGroup{Title: title, Items: items})
+		}
+	}
+
+	var items []docItem
+	for i, v := range c.YouTubeVideoIdeas {
+		items = append(items, docItem{
+			Label:  fmt.Sprintf("Idea %d", i+1),
+			Fields: []docField{{"Title", v.Title}, {"Hook", v.Hook}},
+			List:   v.BulletPoints,
+			Flags:  flagsFor("youtube_video_ideas", i),
+		})
+	}
+	add("youtube", "Video Ideas", items)
+
+	items = nil
+	for i, t := range c.TwitterPosts {
+		items = append(items, docItem{Label: fmt.Sprintf("Tweet %d", i+1), Text: t, Flags: flagsFor("twitter_posts", i)})
+	}
+	add("twitter", "Tweets", items)
+	items = nil
+	for i, t := range c.TwitterThreads {
+		items = append(items, docItem{
+			Label:  fmt.Sprintf("Thread %d", i+1),
+			Fields: []docField{{"Title", t.Title}},
+			List:   t.Tweets,
+			Flags:  flagsFor("twitter_threads", i),
+		})
+	}
+	add("twitter", "Threads", items)
+
+	items = nil
+	for i, p := range c.LinkedInPosts {
+		items = append(items, docItem{Label: fmt.Sprintf("Post %d", i+1), Text: p, Flags: flagsFor("linkedin_posts", i)})
+	}
+	add("linkedin", "Posts", items)
+
+	items = nil
+	for i, r := range c.InstagramReels {
+		items = append(items, docItem{
+			Label:  fmt.Sprintf("Reel %d", i+1),
+			Text:   r.Idea,
+			Fields: []docField{{"Style", r.CaptionStyle}},
+			Flags:  flagsFor("instagram_reels", i),
+		})
+	}
+	add("instagram", "Reel Ideas", items)
+	items = nil
+	for i, p := range c.InstagramPosts {
+		items = append(items, docItem{Label: fmt.Sprintf("Caption %d", i+1), Text: p, Flags: flagsFor("instagram_posts", i)})
+	}
+	add("instagram", "Post Captions", items)
+
+	var out []docSection
+	for _, platform := range types.Platforms {
+		if len(groups[platform]) > 0 {
+			out = append(out, docSection{Platform: platform, Title: platformTitles[platform], Groups: groups[platform]})
+		}
+	}
+	return out
+}
+
+func (doc Document) heading() string {
+	title := doc.Title
+	if title == "" {
+		title = "Content Ideas"
+	}
+	if doc.Brand != "" {
+		title += " - " + doc.Brand
+	}
+	return title
+}
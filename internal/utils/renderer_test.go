@@ -0,0 +1,66 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite golden files from the current output")
+
+// loadDocument reads testdata/document.json, a bundle as it comes back
+// from the database, including a thread stored under the old "body" key.
+func loadDocument(t *testing.T) Document {
+	t.Helper()
+	raw, err := os.ReadFile(filepath.Join("testdata", "document.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc Document
+	if err := json.Unmarshal(raw, &doc); err != nil {
+		t.Fatal(err)
+	}
+	return doc
+}
+
+// TestRenderGolden renders the same document in every format and compares
+// each with testdata/render/document<ext>. Run go test -update after an
+// intended layout change and review the new files.
+func TestRenderGolden(t *testing.T) {
+	doc := loadDocument(t)
+	if got := doc.Content.TwitterThreads[1].Tweets; len(got) != 2 {
+		t.Fatalf("thread stored under body decoded to %q", got)
+	}
+	for _, format := range builtinRenderers().Formats() {
+		t.Run(format, func(t *testing.T) {
+			r, err := builtinRenderers().Get(format)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got bytes.Buffer
+			if err := r.Render(&got, doc); err != nil {
+				t.Fatal(err)
+			}
+			path := filepath.Join("testdata", "render", "document"+r.Extension())
+			if *update {
+				if err := os.WriteFile(path, got.Bytes(), 0644); err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			want, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatalf("%v (run go test -update to create it)", err)
+			}
+			if !bytes.Equal(got.Bytes(), want) {
+				if format == "pdf" {
+					t.Fatalf("PDF output differs from %s (%d bytes, want %d)", path, got.Len(), len(want))
+				}
+				t.Errorf("output differs from %s:\n--- got\n%s\n--- want\n%s", path, got.Bytes(), want)
+			}
+		})
+	}
+}
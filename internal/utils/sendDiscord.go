@@ -2,178 +2,144 @@ package utils
 
 import (
 	"bytes"
+	"context"
+	"encoding/json"
 	"fmt"
 	"io"
 	"mime/multipart"
 	"net/http"
+	"net/url"
 	"os"
-	"strings"
-	"time"
-	"unicode"
+	"path/filepath"
 
 	"github.com/iamlucif3r/sarjan/internal/types"
-	"github.com/jung-kurt/gofpdf"
 )
 
-func sanitizeText(s string) string {
-	s = strings.ReplaceAll(s, "â€™", "'")
-	s = strings.ReplaceAll(s, "â€“", "-")
-	s = strings.ReplaceAll(s, "â€œ", "\"")
-	s = strings.ReplaceAll(s, "â€", "\"")
-	s = strings.ReplaceAll(s, "â€˜", "'")
-	s = strings.ReplaceAll(s, "â€¦", "...")
-	return strings.Map(func(r rune) rune {
-		if r > unicode.MaxASCII || !unicode.IsPrint(r) {
-			return -1
-		}
-		return r
-	}, s)
+// discordNotifier posts bundles as embeds, or only their files in
+// attachments mode.
+type discordNotifier struct {
+	notifierBase
+	url    string
+	mode   string
+	thread bool
 }
 
-func SendPDFToDiscord(webhookURL, pdfPath string) error {
-	now := time.Now()
-	timestamp := now.Format("20060102_1504")
-	report := fmt.Sprintf("pwnspectrum_%s.pdf", timestamp)
-
-	file, err := os.Open(pdfPath)
-	if err != nil {
-		return fmt.Errorf("failed to open PDF file: %w", err)
+func (n discordNotifier) Notify(ctx context.Context, d Delivery) error {
+	if n.mode == "attachments" {
+		return SendFilesToDiscord(ctx, n.client, n.url, d.Files...)
 	}
-	defer file.Close()
-
-	var body bytes.Buffer
-	writer := multipart.NewWriter(&body)
+	return SendDocumentToDiscord(ctx, n.client, n.url, d.Doc, n.thread, d.Files...)
+}
 
-	_ = writer.WriteField("payload_json", `{"content":"Here's your curated content 🚀"}`)
+// SendFilesToDiscord posts the given files as attachments in one webhook
+// message.
+func SendFilesToDiscord(ctx context.Context, client *WebhookClient, webhookURL string, paths ...string) error {
+	_, err := postDiscord(ctx, client, webhookURL, types.DiscordMessage{Content: "Here's your curated content 🚀"}, "", paths)
+	return err
+}
 
-	part, err := writer.CreateFormFile("file", report)
-	if err != nil {
-		return fmt.Errorf("failed to create form file: %w", err)
+// SendDocumentToDiscord posts doc as one embed per platform, split across
+// as many messages as Discord's limits need, with the rendered files
+// attached to the first message. With thread set the first message opens
+// a new post named after the bundle and the rest are sent into it, which
+// needs a forum channel webhook.
+func SendDocumentToDiscord(ctx context.Context, client *WebhookClient, webhookURL string, doc Document, thread bool, paths ...string) error {
+	threadName := ""
+	if thread {
+		threadName = discordThreadName(doc)
 	}
+	messages := discordMessages(doc, threadName)
 
-	if _, err = io.Copy(part, file); err != nil {
-		return fmt.Errorf("failed to copy PDF content: %w", err)
+	threadID := ""
+	for i, msg := range messages {
+		var files []string
+		if i == 0 {
+			files = paths
+		}
+		sent, err := postDiscord(ctx, client, webhookURL, msg, threadID, files)
+		if err != nil {
+			return fmt.Errorf("message %d of %d: %w", i+1, len(messages), err)
+		}
+		if thread && i == 0 {
+			// A forum post's channel is the thread itself.
+			threadID = sent.ChannelID
+		}
 	}
+	return nil
+}
 
-	if err := writer.Close(); err != nil {
-		return fmt.Errorf("failed to close multipart writer: %w", err)
-	}
+// discordResponse is the part of the created message we read back.
+type discordResponse struct {
+	ID        string `json:"id"`
+	ChannelID string `json:"channel_id"`
+}
 
-	req, err := http.NewRequest("POST", webhookURL, &body)
-	if err != nil {
-		return fmt.Errorf("failed to create HTTP request: %w", err)
+// postDiscord executes the webhook with wait=true so Discord returns the
+// created message. Files turn the request into a multipart upload with
+// the message as payload_json.
+func postDiscord(ctx context.Context, client *WebhookClient, webhookURL string, msg types.DiscordMessage, threadID string, paths []string) (discordResponse, error) {
+	var sent discordResponse
+	if client == nil {
+		client = NewWebhookClient(0, 0, 0)
 	}
-	req.Header.Set("Content-Type", writer.FormDataContentType())
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	target, err := url.Parse(webhookURL)
 	if err != nil {
-		return fmt.Errorf("failed to send PDF to Discord: %w", err)
+		return sent, fmt.Errorf("invalid Discord webhook URL: %w", err)
 	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode >= 400 {
-		return fmt.Errorf("Discord API returned error status: %s", resp.Status)
+	query := target.Query()
+	if threadID != "" {
+		query.Set("thread_id", threadID)
 	}
+	query.Set("wait", "true")
+	target.RawQuery = query.Encode()
 
-	return nil
-}
-
-func GenerateContentIdeasPDF(content types.ContentIdeas, filename string) error {
-	if err := os.MkdirAll("output", 0755); err != nil {
-		return fmt.Errorf("failed to create output directory: %w", err)
+	payload, err := json.Marshal(msg)
+	if err != nil {
+		return sent, fmt.Errorf("failed to encode Discord message: %w", err)
 	}
 
-	pdf := gofpdf.New("P", "mm", "A4", "")
-	pdf.SetFont("Arial", "", 12)
-	pdf.SetTitle("Generated Content Ideas", false)
-	pdf.AddPage()
-
-	pdf.SetFont("Arial", "B", 16)
-	pdf.Cell(0, 10, sanitizeText("Content Ideas Summary - "+time.Now().Format("02 Jan 2006 15:04")))
-	pdf.Ln(12)
-	pdf.SetFont("Arial", "", 12)
-
-	if len(content.YouTubeVideoIdeas) > 0 {
-		pdf.SetFont("Arial", "B", 14)
-		pdf.Cell(0, 10, sanitizeText("YouTube"))
-		pdf.Ln(8)
-		pdf.SetFont("Arial", "", 12)
-
-		for i, vid := range content.YouTubeVideoIdeas {
-			pdf.SetFont("Arial", "B", 12)
-			pdf.Cell(0, 8, sanitizeText(fmt.Sprintf("Idea %d:", i+1)))
-			pdf.Ln(6)
-			pdf.SetFont("Arial", "", 12)
-			pdf.MultiCell(0, 6, sanitizeText("Title: "+vid.Title), "", "", false)
-			pdf.MultiCell(0, 6, sanitizeText("Hook: "+vid.Hook), "", "", false)
-			if len(vid.BulletPoints) > 0 {
-				pdf.MultiCell(0, 6, sanitizeText("Bullet Points:"), "", "", false)
-				for _, bp := range vid.BulletPoints {
-					pdf.MultiCell(0, 6, sanitizeText("- "+bp), "", "", false)
-				}
+	var body bytes.Buffer
+	contentType := "application/json"
+	if len(paths) == 0 {
+		body.Write(payload)
+	} else {
+		writer := multipart.NewWriter(&body)
+		_ = writer.WriteField("payload_json", string(payload))
+		for i, path := range paths {
+			if err := attachFile(writer, fmt.Sprintf("files[%d]", i), path); err != nil {
+				return sent, err
 			}
-			pdf.Ln(4)
 		}
+		if err := writer.Close(); err != nil {
+			return sent, fmt.Errorf("failed to close multipart writer: %w", err)
+		}
+		contentType = writer.FormDataContentType()
 	}
 
-	if len(content.LinkedInPosts) > 0 {
-		pdf.SetFont("Arial", "B", 14)
-		pdf.Cell(0, 10, sanitizeText("LinkedIn"))
-		pdf.Ln(8)
-		pdf.SetFont("Arial", "", 12)
-
-		pdf.MultiCell(0, 6, sanitizeText(strings.Join(content.LinkedInPosts, "\n\n")), "", "", false)
-		pdf.Ln(3)
+	resp, err := client.Post(ctx, target.String(), http.Header{"Content-Type": {contentType}}, body.Bytes())
+	if err != nil {
+		return sent, fmt.Errorf("failed to send message to Discord: %w", err)
 	}
-
-	if len(content.TwitterPosts) > 0 || len(content.TwitterThreads) > 0 {
-		pdf.SetFont("Arial", "B", 14)
-		pdf.Cell(0, 10, sanitizeText("Twitter"))
-		pdf.Ln(8)
-		pdf.SetFont("Arial", "", 12)
-
-		if len(content.TwitterPosts) > 0 {
-			pdf.MultiCell(0, 6, sanitizeText("Tweets:"), "", "", false)
-			for i, tweet := range content.TwitterPosts {
-				pdf.MultiCell(0, 6, sanitizeText(fmt.Sprintf("- %d: %s", i+1, tweet)), "", "", false)
-			}
-			pdf.Ln(3)
-		}
-
-		if len(content.TwitterThreads) > 0 {
-			for i, thread := range content.TwitterThreads {
-				pdf.MultiCell(0, 6, sanitizeText(fmt.Sprintf("Thread %d: %s", i+1, thread.Title)), "", "", false)
-				for _, line := range thread.Body {
-					pdf.MultiCell(0, 6, sanitizeText("• "+line), "", "", false)
-				}
-				pdf.Ln(3)
-			}
-		}
+	if err := json.Unmarshal(resp.Body, &sent); err != nil {
+		return sent, fmt.Errorf("failed to decode Discord response: %w", err)
 	}
+	return sent, nil
+}
 
-	if len(content.InstagramReels) > 0 || len(content.InstagramPosts) > 0 {
-		pdf.SetFont("Arial", "B", 14)
-		pdf.Cell(0, 10, sanitizeText("Instagram"))
-		pdf.Ln(8)
-		pdf.SetFont("Arial", "", 12)
-
-		if len(content.InstagramReels) > 0 {
-			pdf.MultiCell(0, 6, sanitizeText("Reel Ideas:"), "", "", false)
-			for i, reel := range content.InstagramReels {
-				pdf.MultiCell(0, 6, sanitizeText(fmt.Sprintf("- Idea %d: %s", i+1, reel.Idea)), "", "", false)
-				pdf.MultiCell(0, 6, sanitizeText("  Style: "+reel.CaptionStyle), "", "", false)
-			}
-			pdf.Ln(3)
-		}
-
-		if len(content.InstagramPosts) > 0 {
-			pdf.MultiCell(0, 6, sanitizeText("Post Captions:"), "", "", false)
-			for i, post := range content.InstagramPosts {
-				pdf.MultiCell(0, 6, sanitizeText(fmt.Sprintf("- %d: %s", i+1, post)), "", "", false)
-			}
-		}
+func attachFile(writer *multipart.Writer, field, path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", path, err)
 	}
+	defer file.Close()
 
-	return pdf.OutputFileAndClose(filename)
+	part, err := writer.CreateFormFile(field, filepath.Base(path))
+	if err != nil {
+		return fmt.Errorf("failed to create form file: %w", err)
+	}
+	if _, err = io.Copy(part, file); err != nil {
+		return fmt.Errorf("failed to copy %s: %w", path, err)
+	}
+	return nil
 }
@@ -0,0 +1,299 @@
+package utils
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/iamlucif3r/sarjan/internal/types"
+)
+
+const (
+	DefaultWebhookAttempts  = 5
+	DefaultWebhookBaseDelay = time.Second
+	DefaultWebhookMaxDelay  = 30 * time.Second
+)
+
+// WebhookClient posts to chat webhooks. It waits out rate limits announced
+// with X-RateLimit-* headers before sending, honours Retry-After on 429s
+// and retries network errors and 5xx responses with exponential backoff
+// and jitter. Copies made with Recording share its rate-limit state, so
+// one client can serve every job.
+type WebhookClient struct {
+	HTTP        *http.Client
+	MaxAttempts int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+	// OnAttempt, when set, is called after every request.
+	OnAttempt func(types.DeliveryAttempt)
+
+	limits *webhookLimits
+}
+
+// webhookLimits remembers, per webhook, when its bucket next has room.
+type webhookLimits struct {
+	mu      sync.Mutex
+	resetAt map[string]time.Time
+}
+
+// WebhookResponse is a webhook's reply, body included.
+type WebhookResponse struct {
+	StatusCode int
+	Status     string
+	Header     http.Header
+	Body       []byte
+}
+
+func NewWebhookClient(maxAttempts int, baseDelay, maxDelay time.Duration) *WebhookClient {
+	if maxAttempts < 1 {
+		maxAttempts = DefaultWebhookAttempts
+	}
+	if baseDelay <= 0 {
+		baseDelay = DefaultWebhookBaseDelay
+	}
+	if maxDelay < baseDelay {
+		maxDelay = max(baseDelay, DefaultWebhookMaxDelay)
+	}
+	return &WebhookClient{
+		HTTP:        &http.Client{Timeout: 60 * time.Second},
+		MaxAttempts: maxAttempts,
+		BaseDelay:   baseDelay,
+		MaxDelay:    maxDelay,
+		limits:      &webhookLimits{resetAt: map[string]time.Time{}},
+	}
+}
+
+// Recording returns a copy of c that reports every attempt to fn.
+func (c *WebhookClient) Recording(fn func(types.DeliveryAttempt)) *WebhookClient {
+	copied := *c
+	copied.OnAttempt = fn
+	return &copied
+}
+
+// Post sends body to target with the given headers, retrying as described
+// on WebhookClient. A 4xx other than 429 is returned straight away since
+// sending it again will not help.
+func (c *WebhookClient) Post(ctx context.Context, target string, header http.Header, body []byte) (WebhookResponse, error) {
+	key := webhookKey(target)
+	var lastErr error
+	attempts := 0
+	for attempt := 1; attempt <= c.MaxAttempts; attempt++ {
+		attempts = attempt
+		if err := c.limits.wait(ctx, key); err != nil {
+			return WebhookResponse{}, err
+		}
+
+		start := time.Now()
+		resp, err := c.send(ctx, target, header, body)
+		record := types.DeliveryAttempt{Target: RedactWebhookURL(target), Attempt: attempt, At: start, DurationMS: time.Since(start).Milliseconds()}
+
+		retry, delay := false, time.Duration(0)
+		switch {
+		case err != nil:
+			lastErr = err
+			retry, delay = ctx.Err() == nil, c.backoff(attempt)
+		default:
+			record.StatusCode = resp.StatusCode
+			c.limits.note(key, resp.Header)
+			switch {
+			case resp.StatusCode == http.StatusTooManyRequests:
+				lastErr = fmt.Errorf("rate limited: %s", resp.Status)
+				retry, delay = true, retryAfter(resp)
+				if delay <= 0 {
+					delay = c.backoff(attempt)
+				}
+			case resp.StatusCode >= 500:
+				lastErr = fmt.Errorf("server error: %s%s", resp.Status, responseDetail(resp.Body))
+				retry, delay = true, c.backoff(attempt)
+			case resp.StatusCode >= 400:
+				lastErr = fmt.Errorf("webhook returned error status: %s%s", resp.Status, responseDetail(resp.Body))
+			default:
+				lastErr = nil
+				record.MessageID = messageID(resp.Body)
+			}
+		}
+		if lastErr != nil {
+			record.Error = lastErr.Error()
+		}
+		if retry && attempt < c.MaxAttempts {
+			record.RetryAfterMS = delay.Milliseconds()
+		}
+		if c.OnAttempt != nil {
+			c.OnAttempt(record)
+		}
+
+		if lastErr == nil {
+			return resp, nil
+		}
+		if !retry || attempt == c.MaxAttempts {
+			break
+		}
+		if err := sleepContext(ctx, delay); err != nil {
+			return WebhookResponse{}, err
+		}
+	}
+	return WebhookResponse{}, fmt.Errorf("webhook delivery failed after %d attempt(s): %w", attempts, lastErr)
+}
+
+func (c *WebhookClient) send(ctx context.Context, target string, header http.Header, body []byte) (WebhookResponse, error) {
+	req, err := http.NewRequestWithContext(ctx, "POST", target, bytes.NewReader(body))
+	if err != nil {
+		return WebhookResponse{}, fmt.Errorf("failed to create HTTP request: %w", err)
+	}
+	for key, values := range header {
+		req.Header[key] = values
+	}
+
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		// The URL carries the webhook's token; keep it out of errors.
+		if ue, ok := err.(*url.Error); ok {
+			ue.URL = RedactWebhookURL(ue.URL)
+		}
+		return WebhookResponse{}, fmt.Errorf("failed to reach webhook: %w", err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return WebhookResponse{}, fmt.Errorf("failed to read webhook response: %w", err)
+	}
+	return WebhookResponse{StatusCode: resp.StatusCode, Status: resp.Status, Header: resp.Header, Body: data}, nil
+}
+
+// backoff doubles BaseDelay per attempt up to MaxDelay and picks a delay in
+// the upper half of that, so concurrent senders spread out.
+func (c *WebhookClient) backoff(attempt int) time.Duration {
+	d := c.BaseDelay << (attempt - 1)
+	if d > c.MaxDelay || d <= 0 {
+		d = c.MaxDelay
+	}
+	return d/2 + time.Duration(rand.Int63n(int64(d/2)+1))
+}
+
+// wait blocks until the webhook's bucket has room again.
+func (l *webhookLimits) wait(ctx context.Context, key string) error {
+	l.mu.Lock()
+	until := l.resetAt[key]
+	l.mu.Unlock()
+	return sleepContext(ctx, time.Until(until))
+}
+
+// note records an exhausted bucket from X-RateLimit-Remaining and
+// X-RateLimit-Reset-After.
+func (l *webhookLimits) note(key string, header http.Header) {
+	if header.Get("X-RateLimit-Remaining") != "0" {
+		return
+	}
+	after := parseSeconds(header.Get("X-RateLimit-Reset-After"))
+	if after <= 0 {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if until := time.Now().Add(after); until.After(l.resetAt[key]) {
+		l.resetAt[key] = until
+	}
+}
+
+// retryAfter reads a 429's wait from the Retry-After header or the
+// retry_after body field Discord and Telegram send, whichever is longer.
+func retryAfter(resp WebhookResponse) time.Duration {
+	wait := parseSeconds(resp.Header.Get("Retry-After"))
+	var body struct {
+		RetryAfter float64 `json:"retry_after"`
+		Parameters struct {
+			RetryAfter float64 `json:"retry_after"`
+		} `json:"parameters"`
+	}
+	if json.Unmarshal(resp.Body, &body) == nil {
+		seconds := max(body.RetryAfter, body.Parameters.RetryAfter)
+		wait = max(wait, time.Duration(seconds*float64(time.Second)))
+	}
+	return wait
+}
+
+func parseSeconds(v string) time.Duration {
+	s, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+	if err != nil || s < 0 {
+		return 0
+	}
+	return time.Duration(s * float64(time.Second))
+}
+
+// messageID picks the id out of a JSON response, as Discord returns when
+// asked to wait for the message.
+func messageID(body []byte) string {
+	var msg struct {
+		ID string `json:"id"`
+	}
+	if json.Unmarshal(body, &msg) != nil {
+		return ""
+	}
+	return msg.ID
+}
+
+// responseDetail is the start of an error response, for the message.
+func responseDetail(body []byte) string {
+	detail := bytes.TrimSpace(body[:min(len(body), 512)])
+	if len(detail) == 0 {
+		return ""
+	}
+	return " " + string(detail)
+}
+
+// webhookKey identifies a webhook regardless of query parameters.
+func webhookKey(target string) string {
+	u, err := url.Parse(target)
+	if err != nil {
+		return target
+	}
+	return u.Host + u.Path
+}
+
+// RedactWebhookURL masks the token segment of a webhook URL so it can be
+// logged or stored. Telegram's bot<token> segment is masked wherever it
+// sits; otherwise the last segment is the secret.
+func RedactWebhookURL(target string) string {
+	u, err := url.Parse(target)
+	if err != nil {
+		return "(invalid url)"
+	}
+	segments := strings.Split(strings.Trim(u.Path, "/"), "/")
+	masked := false
+	for i, segment := range segments {
+		if strings.HasPrefix(segment, "bot") && strings.Contains(segment, ":") {
+			segments[i] = "botredacted"
+			masked = true
+		}
+	}
+	if !masked && len(segments) > 1 {
+		segments[len(segments)-1] = "redacted"
+	}
+	u.Path = "/" + strings.Join(segments, "/")
+	u.RawQuery = ""
+	u.User = nil
+	return u.String()
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
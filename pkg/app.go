@@ -0,0 +1,89 @@
+package pkg
+
+import (
+	"fmt"
+
+	"github.com/iamlucif3r/sarjan/internal/database"
+	"github.com/iamlucif3r/sarjan/internal/types"
+	"github.com/iamlucif3r/sarjan/internal/utils"
+)
+
+// App owns everything a request or job needs: the configuration, the
+// repository, the LLM client, the fetcher, prompts, brands, renderers and
+// the way notifiers are built. main builds one with NewApp and hands it to
+// the HTTP handlers and the job queue; tests can fill the fields with
+// fakes instead.
+type App struct {
+	Config    types.Config
+	Store     database.Repository
+	LLM       LLMClient
+	Fetcher   *Fetcher
+	Webhooks  *utils.WebhookClient
+	Prompts   *PromptRegistry
+	Brands    *BrandRegistry
+	Renderers utils.Renderers
+	// NewNotifier builds a brand's sinks; it defaults to utils.NewNotifier.
+	NewNotifier func(types.NotifierConfig, utils.NotifierOptions) (utils.Notifier, error)
+}
+
+// NewApp builds the App for cfg around an open repository. store may be
+// nil in tests that do not need one; handlers that need it report so.
+func NewApp(cfg types.Config, store database.Repository) (*App, error) {
+	client, err := NewLLMClient(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("error creating LLM client: %w", err)
+	}
+	prompts, err := NewPromptRegistry(cfg.PromptsDir, cfg.PromptPins)
+	if err != nil {
+		return nil, fmt.Errorf("error loading prompt templates: %w", err)
+	}
+	brands, err := LoadBrands(cfg.BrandsFile, cfg.DefaultBrand, cfg.DiscordWebhookURL, cfg.Env)
+	if err != nil {
+		return nil, fmt.Errorf("error loading brand profiles: %w", err)
+	}
+	renderers, err := utils.NewRenderers(cfg.PDFEmojiFont)
+	if err != nil {
+		return nil, err
+	}
+	return &App{
+		Config:      cfg,
+		Store:       store,
+		LLM:         client,
+		Fetcher:     NewFetcher(FetcherOptionsFromConfig(cfg)),
+		Webhooks:    utils.NewWebhookClient(cfg.WebhookMaxAttempts, cfg.WebhookRetryBase, cfg.WebhookRetryMax),
+		Prompts:     prompts,
+		Brands:      brands,
+		Renderers:   renderers,
+		NewNotifier: utils.NewNotifier,
+	}, nil
+}
+
+// Notifiers builds a brand's delivery sinks. Every HTTP request they make
+// goes through webhooks.
+func (a *App) Notifiers(brand types.BrandProfile, webhooks *utils.WebhookClient) ([]utils.Notifier, error) {
+	opts := utils.NotifierOptions{
+		Webhooks:        webhooks,
+		DiscordDelivery: a.Config.DiscordDelivery,
+		DiscordThreads:  a.Config.DiscordThreads,
+		SMTP: utils.SMTPSettings{
+			Host:     a.Config.SMTPHost,
+			Port:     a.Config.SMTPPort,
+			Username: a.Config.SMTPUsername,
+			Password: a.Config.SMTPPassword,
+			From:     a.Config.SMTPFrom,
+		},
+	}
+	newNotifier := a.NewNotifier
+	if newNotifier == nil {
+		newNotifier = utils.NewNotifier
+	}
+	var notifiers []utils.Notifier
+	for _, cfg := range brand.Notifiers {
+		n, err := newNotifier(cfg, opts)
+		if err != nil {
+			return nil, err
+		}
+		notifiers = append(notifiers, n)
+	}
+	return notifiers, nil
+}
@@ -0,0 +1,234 @@
+package pkg
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/iamlucif3r/sarjan/internal/database"
+	"github.com/iamlucif3r/sarjan/internal/types"
+	"github.com/iamlucif3r/sarjan/internal/utils"
+)
+
+// fakeLLM answers every content prompt from a recorded model response,
+// keeping only the fields the prompt's schema requires.
+type fakeLLM struct {
+	recorded map[string]json.RawMessage
+
+	mu      sync.Mutex
+	prompts int
+}
+
+func newFakeLLM(t *testing.T, recording string) *fakeLLM {
+	t.Helper()
+	raw, err := os.ReadFile(filepath.Join("testdata", "content", recording+".json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := &fakeLLM{}
+	if err := json.Unmarshal(raw, &f.recorded); err != nil {
+		t.Fatal(err)
+	}
+	return f
+}
+
+func (f *fakeLLM) Model() string { return "fake" }
+
+func (f *fakeLLM) Generate(ctx context.Context, prompt string, opts GenerateOptions) (string, error) {
+	return "", errors.New("fakeLLM: Generate is not expected")
+}
+
+func (f *fakeLLM) GenerateJSON(ctx context.Context, prompt string, schema any, opts GenerateOptions) (string, error) {
+	f.mu.Lock()
+	f.prompts++
+	f.mu.Unlock()
+	required, _ := schema.(map[string]any)["required"].([]string)
+	out := map[string]json.RawMessage{}
+	for _, field := range required {
+		value, ok := f.recorded[field]
+		if !ok {
+			return "", fmt.Errorf("fakeLLM: no recorded %s", field)
+		}
+		out[field] = value
+	}
+	data, err := json.Marshal(out)
+	return string(data), err
+}
+
+func (f *fakeLLM) Stream(ctx context.Context, prompt string, opts GenerateOptions, onChunk func(string) error) error {
+	return errors.New("fakeLLM: Stream is not expected")
+}
+
+// recordingNotifier keeps every Delivery instead of sending it.
+type recordingNotifier struct {
+	mu         sync.Mutex
+	deliveries []utils.Delivery
+}
+
+func (n *recordingNotifier) Name() string { return "recorder" }
+func (n *recordingNotifier) Type() string { return "webhook" }
+
+func (n *recordingNotifier) Notify(ctx context.Context, d utils.Delivery) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.deliveries = append(n.deliveries, d)
+	return nil
+}
+
+// newTestApp builds an App around a seeded in-memory SQLite store, a fake
+// model and a brand whose only sink is the returned recorder.
+func newTestApp(t *testing.T) (*App, *recordingNotifier) {
+	t.Helper()
+	repo, err := database.ConnectDB(types.Config{DatabaseURL: "sqlite::memory:", DBPingTimeout: time.Second})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { repo.Close() })
+	if _, err := repo.MigrateUp(); err != nil {
+		t.Fatal(err)
+	}
+	fixtures, err := database.DefaultFixtures()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.(*database.SQLite).SeedArticles(fixtures); err != nil {
+		t.Fatal(err)
+	}
+
+	prompts, err := NewPromptRegistry("", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	renderers, err := utils.NewRenderers("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	brand := DefaultBrandProfile()
+	brand.OutputDir = t.TempDir()
+	brand.Notifiers = []types.NotifierConfig{{Type: "webhook", URL: "https://example.invalid/hook"}}
+
+	recorder := &recordingNotifier{}
+	app := &App{
+		Config: types.Config{
+			GenerationAttempts:    1,
+			GenerationConcurrency: 2,
+			RenderFormats:         []string{"markdown", "json"},
+		},
+		Store:     repo,
+		LLM:       newFakeLLM(t, "valid_all"),
+		Fetcher:   NewFetcher(FetcherOptions{}),
+		Webhooks:  utils.NewWebhookClient(1, time.Millisecond, time.Millisecond),
+		Prompts:   prompts,
+		Brands:    &BrandRegistry{brands: map[string]types.BrandProfile{brand.ID: brand}, defaultID: brand.ID},
+		Renderers: renderers,
+		NewNotifier: func(cfg types.NotifierConfig, opts utils.NotifierOptions) (utils.Notifier, error) {
+			return recorder, nil
+		},
+	}
+	return app, recorder
+}
+
+func TestRunGenerateJob(t *testing.T) {
+	app, recorder := newTestApp(t)
+	job, err := app.Store.CreateJob(types.GenerateRequest{Articles: types.ArticleQuery{Limit: 3}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := app.RunGenerateJob(context.Background(), job); err != nil {
+		t.Fatal(err)
+	}
+
+	job, err = app.Store.GetJob(job.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if job.State != types.JobDone || len(job.Errors) != 0 {
+		t.Fatalf("job state = %s, errors = %v; want done without errors", job.State, job.Errors)
+	}
+	platforms, _ := requirementsByPlatform(ContentRequirements)
+	if got := app.LLM.(*fakeLLM).prompts; got != len(platforms) {
+		t.Errorf("model was prompted %d times, want once per platform (%d)", got, len(platforms))
+	}
+
+	brand, _ := app.Brands.Get("")
+	wantArtifacts := []string{
+		ArtifactPath + "/" + brand.ID + fmt.Sprintf("/content_ideas_%d.md", job.ID),
+		ArtifactPath + "/" + brand.ID + fmt.Sprintf("/content_ideas_%d.json", job.ID),
+	}
+	for _, want := range wantArtifacts {
+		if !slices.Contains(job.Artifacts, want) {
+			t.Errorf("artifacts %q are missing %s", job.Artifacts, want)
+		}
+	}
+	var bundleID int64
+	for _, artifact := range job.Artifacts {
+		fmt.Sscanf(artifact, "/bundles/%d", &bundleID)
+	}
+	bundle, err := app.Store.GetBundle(bundleID)
+	if err != nil {
+		t.Fatalf("bundle from artifacts %q: %v", job.Artifacts, err)
+	}
+	if bundle.JobID == nil || *bundle.JobID != job.ID || bundle.Model != "fake" || len(bundle.ArticleIDs) != 3 {
+		t.Errorf("bundle = %+v, want job %d, model fake and 3 articles", bundle, job.ID)
+	}
+	if len(bundle.Content.TwitterPosts) != 5 || len(bundle.Content.LinkedInPosts) != 1 {
+		t.Errorf("bundle content = %+v, want the recorded posts", bundle.Content)
+	}
+
+	if len(recorder.deliveries) != 1 {
+		t.Fatalf("notifier received %d deliveries, want 1", len(recorder.deliveries))
+	}
+	d := recorder.deliveries[0]
+	if len(d.Files) != 2 || len(d.Doc.Sources) != 3 {
+		t.Errorf("delivery has files %q and %d sources, want 2 files and 3 sources", d.Files, len(d.Doc.Sources))
+	}
+	for _, file := range d.Files {
+		if _, err := os.Stat(file); err != nil {
+			t.Errorf("rendered file: %v", err)
+		}
+	}
+}
+
+// stalledLLM never answers until the request's context ends.
+type stalledLLM struct{ *fakeLLM }
+
+func (s stalledLLM) GenerateJSON(ctx context.Context, prompt string, schema any, opts GenerateOptions) (string, error) {
+	<-ctx.Done()
+	return "", ctx.Err()
+}
+
+func TestRunGenerateJobTimeout(t *testing.T) {
+	app, recorder := newTestApp(t)
+	app.LLM = stalledLLM{app.LLM.(*fakeLLM)}
+	app.Config.JobTimeout = 50 * time.Millisecond
+	job, err := app.Store.CreateJob(types.GenerateRequest{Platforms: []string{"linkedin"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Now()
+	err = app.RunGenerateJob(context.Background(), job)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("RunGenerateJob = %v, want context.DeadlineExceeded", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("job gave up after %v, want about %v", elapsed, app.Config.JobTimeout)
+	}
+	job, err = app.Store.GetJob(job.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if job.State != types.JobFailed || job.Errors[string(types.JobGenerating)] == "" {
+		t.Errorf("job state = %s, errors = %v; want failed while generating", job.State, job.Errors)
+	}
+	if len(recorder.deliveries) != 0 {
+		t.Errorf("a timed-out job delivered %d bundles", len(recorder.deliveries))
+	}
+}
@@ -0,0 +1,138 @@
+package pkg
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/iamlucif3r/sarjan/internal/types"
+	"github.com/iamlucif3r/sarjan/internal/utils"
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultBrandID names the built-in pwnspectrum profile.
+const DefaultBrandID = "pwnspectrum"
+
+// DefaultBrandProfile is the pwnspectrum persona SARJAN shipped with.
+func DefaultBrandProfile() types.BrandProfile {
+	return types.BrandProfile{
+		ID:   DefaultBrandID,
+		Name: "pwnspectrum",
+		Voice: "a faceless, savage, unfiltered cybersecurity content brand that **owns timelines** and **commands respect** from hackers, red teamers, blue teamers, DevSecOps goons, and every script kiddie watching from the shadows.\n\n" +
+			"You’ve got no time for generic corporate cyber yapping. Your content is:\n" +
+			"- Loud where others whisper\n" +
+			"- Deep where others skim\n" +
+			"- Funny, brutal, and smart as hell\n" +
+			"- Bold, clever, and ruthless with boring — it reads like it came from someone who lives in exploits, packets, and logs, not news headlines.",
+		Audience: "Pentesters, red and blue teamers, DevSecOps engineers and threat intel analysts.",
+		Goal:     "Content so tactical and savage it gets bookmarked by pentesters, banned in corporate Slack, and screenshot into threat intel decks without credit.",
+		PlatformStyles: map[string]string{
+			"linkedin":  "“Speak like you got laid off from a unicorn startup and now write like Naval.” Grounded in **practical, operational tactics** — share methods, frameworks, and war stories tied to the news.",
+			"instagram": "“Short, punchy, should slap harder than a 0-day on prod.” Visually engaging, instantly digestible, but hinting at a bigger play hackers will want to dig into.",
+			"twitter":   "“Roast vulnerabilities. Inject humor. Drop 1-liners like reverse shells.” Tactical, witty, and dripping with hacker culture references.",
+		},
+		OutputDir: filepath.Join(OutputDir, DefaultBrandID),
+	}
+}
+
+// BrandRegistry resolves brand IDs to profiles.
+type BrandRegistry struct {
+	brands    map[string]types.BrandProfile
+	defaultID string
+}
+
+// LoadBrands reads brand profiles from a YAML file holding a "brands" list.
+// The built-in pwnspectrum profile is always present unless the file
+// redefines it. A missing file is not an error. Brands without a webhook
+// fall back to fallbackWebhook, and brands without notifiers deliver to
+// that webhook alone; brands without an output directory write to
+// output/<id>. ${VAR} references in notifiers are resolved from env, or
+// from the process environment when env is nil.
+func LoadBrands(path, defaultID, fallbackWebhook string, env map[string]string) (*BrandRegistry, error) {
+	getenv := os.Getenv
+	if env != nil {
+		getenv = func(key string) string { return env[key] }
+	}
+	builtin := DefaultBrandProfile()
+	builtin.DiscordWebhookURL = fallbackWebhook
+	builtin.Notifiers = defaultNotifiers(builtin)
+	r := &BrandRegistry{
+		brands:    map[string]types.BrandProfile{builtin.ID: builtin},
+		defaultID: defaultID,
+	}
+	if r.defaultID == "" {
+		r.defaultID = DefaultBrandID
+	}
+
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil && !os.IsNotExist(err) {
+			return nil, fmt.Errorf("reading brands file: %w", err)
+		}
+		if err == nil {
+			var file struct {
+				Brands []types.BrandProfile `yaml:"brands"`
+			}
+			if err := yaml.Unmarshal(data, &file); err != nil {
+				return nil, fmt.Errorf("parsing brands file %s: %w", path, err)
+			}
+			for _, b := range file.Brands {
+				if b.ID == "" || b.Name == "" {
+					return nil, fmt.Errorf("brands file %s: every brand needs an id and a name", path)
+				}
+				if b.DiscordWebhookURL == "" {
+					b.DiscordWebhookURL = fallbackWebhook
+				}
+				if b.OutputDir == "" {
+					b.OutputDir = filepath.Join(OutputDir, b.ID)
+				}
+				if len(b.Notifiers) == 0 {
+					b.Notifiers = defaultNotifiers(b)
+				}
+				for i, n := range b.Notifiers {
+					n = utils.ExpandNotifierConfig(n, getenv)
+					b.Notifiers[i] = n
+					if err := utils.ValidateNotifierConfig(n); err != nil {
+						return nil, fmt.Errorf("brands file %s: brand %s: %w", path, b.ID, err)
+					}
+				}
+				r.brands[b.ID] = b
+			}
+		}
+	}
+
+	if _, ok := r.brands[r.defaultID]; !ok {
+		return nil, fmt.Errorf("default brand %q is not defined", r.defaultID)
+	}
+	return r, nil
+}
+
+// defaultNotifiers delivers to the brand's Discord webhook, if it has one.
+func defaultNotifiers(b types.BrandProfile) []types.NotifierConfig {
+	if b.DiscordWebhookURL == "" {
+		return nil
+	}
+	return []types.NotifierConfig{{Type: "discord", URL: b.DiscordWebhookURL}}
+}
+
+// Get returns the named brand, or the default brand for an empty id.
+func (r *BrandRegistry) Get(id string) (types.BrandProfile, error) {
+	if id == "" {
+		id = r.defaultID
+	}
+	b, ok := r.brands[id]
+	if !ok {
+		return b, fmt.Errorf("unknown brand %q", id)
+	}
+	return b, nil
+}
+
+func (r *BrandRegistry) List() []types.BrandProfile {
+	brands := make([]types.BrandProfile, 0, len(r.brands))
+	for _, b := range r.brands {
+		brands = append(brands, b)
+	}
+	sort.Slice(brands, func(i, j int) bool { return brands[i].ID < brands[j].ID })
+	return brands
+}
@@ -0,0 +1,89 @@
+package pkg
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestBrandOutputDirsDoNotNest checks every brand gets its own directory
+// under output, since /artifacts/<id>/ serves a brand's whole directory.
+func TestBrandOutputDirsDoNotNest(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "brands.yaml")
+	brands := `brands:
+  - id: blueprint
+    name: Blueprint Defense
+  - id: redline
+    name: Redline
+    output_dir: reports/redline
+`
+	if err := os.WriteFile(path, []byte(brands), 0644); err != nil {
+		t.Fatal(err)
+	}
+	registry, err := LoadBrands(path, "", "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]string{
+		DefaultBrandID: filepath.Join(OutputDir, DefaultBrandID),
+		"blueprint":    filepath.Join(OutputDir, "blueprint"),
+		"redline":      "reports/redline",
+	}
+	list := registry.List()
+	if len(list) != len(want) {
+		t.Fatalf("loaded %d brands, want %d", len(list), len(want))
+	}
+	for _, b := range list {
+		if b.OutputDir != want[b.ID] {
+			t.Errorf("brand %s writes to %q, want %q", b.ID, b.OutputDir, want[b.ID])
+		}
+		for _, other := range list {
+			if other.ID != b.ID && strings.HasPrefix(other.OutputDir+string(filepath.Separator), b.OutputDir+string(filepath.Separator)) {
+				t.Errorf("brand %s's directory %q is inside brand %s's %q", other.ID, other.OutputDir, b.ID, b.OutputDir)
+			}
+		}
+	}
+}
+
+func TestLoadBrandsExpandsNotifierVars(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "brands.yaml")
+	brands := `brands:
+  - id: blueprint
+    name: Blueprint Defense
+    notifiers:
+      - type: slack
+        url: ${BLUEPRINT_SLACK}
+      - type: webhook
+        url: https://cms.example.com/hook
+        headers:
+          Authorization: Bearer ${BLUEPRINT_CMS_TOKEN}
+`
+	if err := os.WriteFile(path, []byte(brands), 0644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("BLUEPRINT_SLACK", "https://process.example.com/not-this-one")
+	env := map[string]string{
+		"BLUEPRINT_SLACK":     "https://hooks.slack.com/services/T0/B0/x",
+		"BLUEPRINT_CMS_TOKEN": "s3cr3t",
+	}
+	registry, err := LoadBrands(path, "", "", env)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := registry.Get("blueprint")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := b.Notifiers[0].URL; got != env["BLUEPRINT_SLACK"] {
+		t.Errorf("slack url = %q, want it from env", got)
+	}
+	if got := b.Notifiers[1].Headers["Authorization"]; got != "Bearer s3cr3t" {
+		t.Errorf("Authorization header = %q", got)
+	}
+
+	if _, err := LoadBrands(path, "", "", map[string]string{}); err == nil || !strings.Contains(err.Error(), "url is required") {
+		t.Errorf("LoadBrands with BLUEPRINT_SLACK unset = %v, want the slack notifier rejected", err)
+	}
+}
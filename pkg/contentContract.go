@@ -0,0 +1,191 @@
+package pkg
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/iamlucif3r/sarjan/internal/types"
+)
+
+// The content contract is types.ContentIdeas plus the requested item
+// counts. Everything the model is told and everything its output is held
+// to comes from it: ContentIdeasSchema is the structured-output format,
+// FormatExample is the prompt's FORMAT section, and CheckContentOutput
+// validates against the same schema. Changing a json tag on ContentIdeas
+// changes all three at once.
+
+// ContentIdeasSchema builds the JSON schema for the fields in reqs, with
+// their item counts pinned. Other fields are left out.
+func ContentIdeasSchema(reqs map[string]ItemCount) map[string]any {
+	schema := JSONSchemaFor(types.ContentIdeas{})
+	properties := schema["properties"].(map[string]any)
+	required := []string{}
+	for field, prop := range properties {
+		count, ok := reqs[field]
+		if !ok {
+			delete(properties, field)
+			continue
+		}
+		prop.(map[string]any)["minItems"] = count.Min
+		prop.(map[string]any)["maxItems"] = count.Max
+		required = append(required, field)
+	}
+	sort.Strings(required)
+	schema["required"] = required
+	return schema
+}
+
+// FormatExample renders the JSON skeleton shown to the model for the
+// fields in reqs, in ContentIdeas field order.
+func FormatExample(reqs map[string]ItemCount) string {
+	t := reflect.TypeOf(types.ContentIdeas{})
+	var b strings.Builder
+	b.WriteString("{")
+	first := true
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		count, ok := reqs[name]
+		if !ok {
+			continue
+		}
+		encoded, _ := json.MarshalIndent(exampleValue(field.Type, max(count.Min, 1)).Interface(), "  ", "  ")
+		if !first {
+			b.WriteString(",")
+		}
+		first = false
+		fmt.Fprintf(&b, "\n  %q: %s", name, encoded)
+	}
+	b.WriteString("\n}")
+	return b.String()
+}
+
+// exampleValue fills t with "string" placeholders, using n items for a
+// slice and three for any slice nested inside it.
+func exampleValue(t reflect.Type, n int) reflect.Value {
+	v := reflect.New(t).Elem()
+	switch t.Kind() {
+	case reflect.String:
+		v.SetString("string")
+	case reflect.Slice:
+		v.Set(reflect.MakeSlice(t, n, n))
+		for i := 0; i < n; i++ {
+			v.Index(i).Set(exampleValue(t.Elem(), 3))
+		}
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			v.Field(i).Set(exampleValue(t.Field(i).Type, 3))
+		}
+	}
+	return v
+}
+
+// CheckContentOutput parses raw model output and checks it against the
+// contract for reqs before decoding it. Checking the raw JSON rather than
+// the decoded struct catches misnamed keys, which would otherwise decode
+// silently to empty fields.
+func CheckContentOutput(output string, reqs map[string]ItemCount) (types.ContentIdeas, []string) {
+	var ideas types.ContentIdeas
+	raw, err := repairJSONOutput(output)
+	if err != nil {
+		return ideas, []string{err.Error()}
+	}
+	var doc any
+	if err := json.Unmarshal(raw, &doc); err != nil {
+		return ideas, []string{err.Error()}
+	}
+	var violations []string
+	checkContract("", doc, ContentIdeasSchema(reqs), &violations)
+	if len(violations) > 0 {
+		return ideas, violations
+	}
+	if err := json.Unmarshal(raw, &ideas); err != nil {
+		return ideas, []string{fmt.Sprintf("output does not match the expected structure: %v", err)}
+	}
+	return ideas, nil
+}
+
+// checkContract walks a decoded JSON value alongside its schema. Beyond
+// the schema itself it requires every string to be non-blank and every
+// array without an explicit minItems to be non-empty, since an empty hook
+// or thread is never useful content.
+func checkContract(path string, v any, schema map[string]any, violations *[]string) {
+	fail := func(format string, args ...any) {
+		subject := path
+		if subject == "" {
+			subject = "output"
+		}
+		*violations = append(*violations, subject+" "+fmt.Sprintf(format, args...))
+	}
+	switch schema["type"] {
+	case "object":
+		obj, ok := v.(map[string]any)
+		if !ok {
+			fail("must be an object")
+			return
+		}
+		properties, _ := schema["properties"].(map[string]any)
+		required, _ := schema["required"].([]string)
+		for _, name := range required {
+			if _, ok := obj[name]; !ok {
+				*violations = append(*violations, joinPath(path, name)+" is missing")
+			}
+		}
+		keys := make([]string, 0, len(obj))
+		for key := range obj {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			prop, ok := properties[key].(map[string]any)
+			if !ok {
+				*violations = append(*violations, joinPath(path, key)+" is not part of the format")
+				continue
+			}
+			checkContract(joinPath(path, key), obj[key], prop, violations)
+		}
+	case "array":
+		items, ok := v.([]any)
+		if v == nil {
+			// A nil Go slice encodes as null; treat it as empty.
+			ok = true
+		}
+		if !ok {
+			fail("must be an array")
+			return
+		}
+		minItems, hasMin := schema["minItems"].(int)
+		maxItems, hasMax := schema["maxItems"].(int)
+		switch {
+		case hasMin && hasMax && minItems == maxItems && len(items) != minItems:
+			fail("must contain exactly %d items, got %d", minItems, len(items))
+		case hasMin && len(items) < minItems, hasMax && len(items) > maxItems:
+			fail("must contain %d-%d items, got %d", minItems, maxItems, len(items))
+		case !hasMin && len(items) == 0:
+			fail("is empty")
+		}
+		itemSchema, _ := schema["items"].(map[string]any)
+		for i, item := range items {
+			checkContract(fmt.Sprintf("%s[%d]", path, i), item, itemSchema, violations)
+		}
+	case "string":
+		s, ok := v.(string)
+		if !ok {
+			fail("must be a string")
+			return
+		}
+		if strings.TrimSpace(s) == "" {
+			fail("is empty")
+		}
+	}
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
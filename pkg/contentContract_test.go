@@ -0,0 +1,95 @@
+package pkg
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/iamlucif3r/sarjan/internal/types"
+)
+
+var update = flag.Bool("update", false, "rewrite golden files from the current output")
+
+// checkGolden compares got with testdata/<name>, or rewrites the file
+// when -update is set.
+func checkGolden(t *testing.T, name string, got []byte) {
+	t.Helper()
+	path := filepath.Join("testdata", name)
+	if *update {
+		if err := os.WriteFile(path, got, 0644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("%v (run go test -update to create it)", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("%s differs from the golden file:\n--- got\n%s\n--- want\n%s", name, got, want)
+	}
+}
+
+func platformRequirements(platform string) map[string]ItemCount {
+	_, split := requirementsByPlatform(ContentRequirements)
+	return split[platform]
+}
+
+// TestCheckContentOutputGolden feeds recorded model output through
+// CheckContentOutput. Each recording's golden file holds the decoded
+// content and the violations the repair prompt would be sent.
+func TestCheckContentOutputGolden(t *testing.T) {
+	cases := []struct {
+		recording string
+		reqs      map[string]ItemCount
+	}{
+		{"valid_all", ContentRequirements},
+		{"fenced_twitter", platformRequirements("twitter")},
+		{"misnamed_keys", platformRequirements("twitter")},
+		{"thread_body", platformRequirements("twitter")},
+		{"broken_json", platformRequirements("linkedin")},
+		{"wrong_counts", platformRequirements("instagram")},
+		{"prose", platformRequirements("linkedin")},
+	}
+	for _, c := range cases {
+		t.Run(c.recording, func(t *testing.T) {
+			output, err := os.ReadFile(filepath.Join("testdata", "content", c.recording+".json"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			ideas, violations := CheckContentOutput(string(output), c.reqs)
+			got, err := json.MarshalIndent(struct {
+				Ideas      types.ContentIdeas `json:"ideas"`
+				Violations []string           `json:"violations"`
+			}{ideas, violations}, "", "  ")
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkGolden(t, filepath.Join("content", c.recording+".golden"), append(got, '\n'))
+		})
+	}
+}
+
+// TestStoredThreadBody checks that bundles stored with thread tweets under
+// "body" still decode, even though the contract rejects that key from the
+// model.
+func TestStoredThreadBody(t *testing.T) {
+	output, err := os.ReadFile(filepath.Join("testdata", "content", "thread_body.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ideas types.ContentIdeas
+	if err := json.Unmarshal(output, &ideas); err != nil {
+		t.Fatal(err)
+	}
+	if got := ideas.TwitterThreads[0].Tweets; len(got) != 3 || got[0] != "1/ Auth bypass." {
+		t.Errorf("thread tweets = %q, want the three tweets from body", got)
+	}
+}
+
+func TestFormatExampleGolden(t *testing.T) {
+	checkGolden(t, filepath.Join("content", "format_example.golden"), []byte(FormatExample(ContentRequirements)+"\n"))
+}
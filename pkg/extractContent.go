@@ -0,0 +1,260 @@
+package pkg
+
+import (
+	"math"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/iamlucif3r/sarjan/internal/types"
+	"golang.org/x/net/html"
+)
+
+var (
+	// boilerplateSelector removes elements that never hold article text.
+	boilerplateSelector = "script, style, noscript, template, iframe, svg, canvas, form, button, input, select, " +
+		"nav, header, footer, aside, [role=navigation], [role=banner], [role=contentinfo], [aria-hidden=true]"
+	// boilerplateHint matches class/id names of cookie banners, share bars
+	// and other chrome that sites put inside the content area.
+	boilerplateHint = regexp.MustCompile(`(?i)cookie|consent|gdpr|banner|newsletter|subscribe|share|social|comment|sidebar|related|promo|advert|sponsor|popup|modal|breadcrumb|menu|footer|masthead`)
+	// contentHint matches class/id names that usually wrap the article body.
+	contentHint = regexp.MustCompile(`(?i)article|content|entry|post|story|body|main|text`)
+	whitespace  = regexp.MustCompile(`[ \t\r\f\v]+`)
+	blankLines  = regexp.MustCompile(`\n{3,}`)
+)
+
+// ExtractMainContent pulls the readable article body and metadata out of a
+// page. It follows the readability approach: strip boilerplate, score each
+// paragraph's ancestors by text density, then serialise the best-scoring
+// container keeping headings, lists, quotes and code blocks.
+func ExtractMainContent(page string, pageURL string) (types.ExtractedArticle, error) {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(page))
+	if err != nil {
+		return types.ExtractedArticle{}, err
+	}
+
+	extracted := extractMetadata(doc, pageURL)
+
+	doc.Find(boilerplateSelector).Remove()
+	doc.Find("[class], [id]").Each(func(_ int, s *goquery.Selection) {
+		hint := s.AttrOr("class", "") + " " + s.AttrOr("id", "")
+		if boilerplateHint.MatchString(hint) && !contentHint.MatchString(hint) && goquery.NodeName(s) != "body" {
+			s.Remove()
+		}
+	})
+
+	extracted.Text = serialiseContent(bestContentNode(doc))
+	return extracted, nil
+}
+
+func extractMetadata(doc *goquery.Document, pageURL string) types.ExtractedArticle {
+	meta := func(keys ...string) string {
+		for _, k := range keys {
+			sel := `meta[property="` + k + `"], meta[name="` + k + `"], meta[itemprop="` + k + `"]`
+			if v := strings.TrimSpace(doc.Find(sel).First().AttrOr("content", "")); v != "" {
+				return v
+			}
+		}
+		return ""
+	}
+
+	extracted := types.ExtractedArticle{URL: pageURL}
+	extracted.Title = meta("og:title", "twitter:title")
+	if extracted.Title == "" {
+		extracted.Title = strings.TrimSpace(doc.Find("title").First().Text())
+	}
+	extracted.Author = meta("author", "article:author", "parsely-author", "sailthru.author", "byl")
+	if extracted.Author == "" {
+		extracted.Author = strings.TrimSpace(doc.Find(`[rel=author], [itemprop=author]`).First().Text())
+	}
+
+	published := meta("article:published_time", "og:published_time", "datePublished", "pubdate", "publish-date", "date", "dc.date")
+	if published == "" {
+		published = doc.Find("time[datetime]").First().AttrOr("datetime", "")
+	}
+	if t, ok := parseLooseTime(published); ok {
+		extracted.PublishedAt = &t
+	}
+
+	canonical := doc.Find(`link[rel=canonical]`).First().AttrOr("href", "")
+	if canonical == "" {
+		canonical = meta("og:url")
+	}
+	if canonical != "" {
+		if base, err := url.Parse(pageURL); err == nil {
+			if ref, err := url.Parse(canonical); err == nil {
+				canonical = base.ResolveReference(ref).String()
+			}
+		}
+	}
+	extracted.CanonicalURL = canonical
+	return extracted
+}
+
+func parseLooseTime(v string) (time.Time, bool) {
+	v = strings.TrimSpace(v)
+	if v == "" {
+		return time.Time{}, false
+	}
+	layouts := []string{time.RFC3339, "2006-01-02T15:04:05Z0700", "2006-01-02T15:04:05", "2006-01-02 15:04:05", "2006-01-02", time.RFC1123Z, time.RFC1123}
+	for _, layout := range layouts {
+		if t, err := time.Parse(layout, v); err == nil {
+			return t.UTC(), true
+		}
+	}
+	return time.Time{}, false
+}
+
+// bestContentNode scores the parents and grandparents of every paragraph
+// and returns the highest scoring container, falling back to <article>,
+// <main> or <body>.
+func bestContentNode(doc *goquery.Document) *goquery.Selection {
+	scores := map[*html.Node]float64{}
+	var order []*html.Node
+	add := func(n *html.Node, v float64) {
+		if n == nil || n.Type != html.ElementNode {
+			return
+		}
+		if _, ok := scores[n]; !ok {
+			order = append(order, n)
+			scores[n] = initialScore(goquery.NewDocumentFromNode(n).Selection)
+		}
+		scores[n] += v
+	}
+
+	doc.Find("p, pre, td").Each(func(_ int, p *goquery.Selection) {
+		text := strings.TrimSpace(p.Text())
+		if len(text) < 25 {
+			return
+		}
+		score := 1 + float64(strings.Count(text, ",")) + math.Min(float64(len(text))/100, 3)
+		parent := p.Parent()
+		if parent.Length() == 0 {
+			return
+		}
+		add(parent.Nodes[0], score)
+		if gp := parent.Parent(); gp.Length() > 0 {
+			add(gp.Nodes[0], score/2)
+		}
+	})
+
+	var best *html.Node
+	bestScore := 0.0
+	for _, n := range order {
+		sel := goquery.NewDocumentFromNode(n).Selection
+		score := scores[n] * (1 - linkDensity(sel))
+		if score > bestScore {
+			best, bestScore = n, score
+		}
+	}
+	if best != nil {
+		return doc.FindNodes(best)
+	}
+	for _, sel := range []string{"article", "main", "[role=main]", "body"} {
+		if s := doc.Find(sel).First(); s.Length() > 0 {
+			return s
+		}
+	}
+	return doc.Selection
+}
+
+func initialScore(s *goquery.Selection) float64 {
+	score := 0.0
+	switch goquery.NodeName(s) {
+	case "article", "main":
+		score += 10
+	case "div", "section":
+		score += 5
+	case "pre", "td", "blockquote":
+		score += 3
+	case "ul", "ol", "dl", "form", "li":
+		score -= 3
+	case "h1", "h2", "h3", "h4", "h5", "h6", "th":
+		score -= 5
+	}
+	hint := s.AttrOr("class", "") + " " + s.AttrOr("id", "")
+	if contentHint.MatchString(hint) {
+		score += 25
+	}
+	if boilerplateHint.MatchString(hint) {
+		score -= 25
+	}
+	return score
+}
+
+func linkDensity(s *goquery.Selection) float64 {
+	total := len(strings.TrimSpace(s.Text()))
+	if total == 0 {
+		return 0
+	}
+	links := 0
+	s.Find("a").Each(func(_ int, a *goquery.Selection) {
+		links += len(strings.TrimSpace(a.Text()))
+	})
+	return float64(links) / float64(total)
+}
+
+// serialiseContent renders the container as plain text with light
+// Markdown-style markers so structure survives into the prompt.
+func serialiseContent(root *goquery.Selection) string {
+	var b strings.Builder
+	root.Find("h1, h2, h3, h4, h5, h6, p, pre, ul, ol, blockquote, table").Each(func(_ int, s *goquery.Selection) {
+		if s.ParentsFiltered("pre, ul, ol, blockquote, table").Length() > 0 {
+			return
+		}
+		switch name := goquery.NodeName(s); name {
+		case "h1", "h2", "h3", "h4", "h5", "h6":
+			if text := cleanInline(s.Text()); text != "" {
+				b.WriteString(strings.Repeat("#", int(name[1]-'0')) + " " + text + "\n\n")
+			}
+		case "pre":
+			if text := strings.Trim(s.Text(), "\n"); strings.TrimSpace(text) != "" {
+				b.WriteString("```\n" + text + "\n```\n\n")
+			}
+		case "ul", "ol":
+			s.ChildrenFiltered("li").Each(func(i int, li *goquery.Selection) {
+				text := cleanInline(li.Text())
+				if text == "" {
+					return
+				}
+				if name == "ol" {
+					b.WriteString(strconv.Itoa(i+1) + ". " + text + "\n")
+				} else {
+					b.WriteString("- " + text + "\n")
+				}
+			})
+			b.WriteString("\n")
+		case "blockquote":
+			if text := cleanInline(s.Text()); text != "" {
+				b.WriteString("> " + text + "\n\n")
+			}
+		case "p", "table":
+			if linkDensity(s) > 0.5 {
+				return
+			}
+			if text := cleanInline(s.Text()); text != "" {
+				b.WriteString(text + "\n\n")
+			}
+		}
+	})
+
+	text := strings.TrimSpace(blankLines.ReplaceAllString(b.String(), "\n\n"))
+	if text == "" {
+		text = cleanInline(root.Text())
+	}
+	return text
+}
+
+func cleanInline(s string) string {
+	lines := strings.Split(s, "\n")
+	var kept []string
+	for _, line := range lines {
+		if line = strings.TrimSpace(whitespace.ReplaceAllString(line, " ")); line != "" {
+			kept = append(kept, line)
+		}
+	}
+	return strings.Join(kept, " ")
+}
@@ -1,53 +1,186 @@
 package pkg
 
 import (
-	"io"
+	"context"
+	"database/sql"
+	"fmt"
 	"log"
-	"net/http"
+	"net/url"
 	"strings"
+	"sync"
+	"time"
 
-	"github.com/PuerkitoBio/goquery"
+	"github.com/iamlucif3r/sarjan/internal/database"
 	"github.com/iamlucif3r/sarjan/internal/types"
 )
 
-func EnrichArticleContent(articles []types.JudgedArticle) []types.JudgedArticle {
-	for i, article := range articles {
-		if article.URL == "" {
-			log.Printf("Skipping article %s due to empty URL", article.Title)
+type EnrichStatus string
+
+const (
+	EnrichFetched EnrichStatus = "fetched"
+	EnrichCached  EnrichStatus = "cached"
+	EnrichSkipped EnrichStatus = "skipped"
+	EnrichFailed  EnrichStatus = "failed"
+)
+
+// EnrichResult reports what happened to one article during enrichment.
+type EnrichResult struct {
+	ArticleID int           `json:"article_id"`
+	URL       string        `json:"url"`
+	Status    EnrichStatus  `json:"status"`
+	Error     string        `json:"error,omitempty"`
+	Duration  time.Duration `json:"duration"`
+}
+
+type EnrichOptions struct {
+	// Concurrency is the number of articles processed at once.
+	Concurrency int
+	// HostDelay is the minimum gap between two fetches to the same host.
+	HostDelay time.Duration
+}
+
+// EnrichArticleContent replaces each article's upstream description with
+// the main text of its web page, reading through the enrichment cache so a
+// URL is only fetched once. Articles whose page cannot be fetched, or
+// yields less text than the description, keep the description. Both
+// returned slices are in the same order as the input.
+func EnrichArticleContent(ctx context.Context, db database.Repository, fetcher *Fetcher, articles []types.JudgedArticle, opts EnrichOptions) ([]types.JudgedArticle, []EnrichResult) {
+	if opts.Concurrency < 1 {
+		opts.Concurrency = 1
+	}
+	enriched := make([]types.JudgedArticle, len(articles))
+	copy(enriched, articles)
+	results := make([]EnrichResult, len(articles))
+	limiter := newHostLimiter(opts.HostDelay)
+
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < min(opts.Concurrency, len(articles)); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				start := time.Now()
+				results[i] = enrichOne(ctx, db, fetcher, limiter, &enriched[i])
+				results[i].Duration = time.Since(start)
+			}
+		}()
+	}
+
+	for i := range articles {
+		if ctx.Err() != nil {
+			results[i] = EnrichResult{ArticleID: articles[i].ID, URL: articles[i].URL, Status: EnrichSkipped, Error: ctx.Err().Error()}
 			continue
 		}
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+	return enriched, results
+}
+
+func enrichOne(ctx context.Context, db database.Repository, fetcher *Fetcher, limiter *hostLimiter, article *types.JudgedArticle) EnrichResult {
+	result := EnrichResult{ArticleID: article.ID, URL: article.URL}
+	failed := func(err error) EnrichResult {
+		log.Printf("[WARN] Failed to enrich %s: %v", article.Title, err)
+		result.Status = EnrichFailed
+		result.Error = err.Error()
+		return result
+	}
 
-		fullContent, err := FetchFullContent(article.URL)
+	if article.URL == "" {
+		result.Status = EnrichSkipped
+		result.Error = "empty URL"
+		return result
+	}
+
+	extracted, err := db.GetCachedContent(article.URL)
+	if err == nil {
+		result.Status = EnrichCached
+	} else {
+		if err != sql.ErrNoRows {
+			log.Println("[ERROR]", err)
+		}
+		page, err := fetcher.fetch(ctx, article.URL, limiter)
 		if err != nil {
-			log.Printf("Failed to fetch full content for %s: %v", article.Title, err)
-			continue
+			return failed(err)
 		}
+		extracted, err = ExtractMainContent(page, article.URL)
+		if err != nil {
+			return failed(err)
+		}
+		if err := db.SaveCachedContent(extracted); err != nil {
+			log.Println("[ERROR]", err)
+		}
+		result.Status = EnrichFetched
+	}
 
-		articles[i].Content = CleanHTMLContent(fullContent)
+	if len(extracted.Text) > len(article.Content) {
+		article.Content = extracted.Text
 	}
-	return articles
+	return result
 }
 
-func FetchFullContent(url string) (string, error) {
-	resp, err := http.Get(url)
-	if err != nil {
-		return "", err
+// SummariseEnrichFailures renders failed results as one line for the job's
+// stage errors, or "" when nothing failed.
+func SummariseEnrichFailures(results []EnrichResult) string {
+	var failures []string
+	for _, r := range results {
+		if r.Status == EnrichFailed {
+			failures = append(failures, fmt.Sprintf("article %d: %s", r.ArticleID, r.Error))
+		}
+	}
+	if len(failures) == 0 {
+		return ""
 	}
-	defer resp.Body.Close()
+	return fmt.Sprintf("%d of %d articles not enriched: %s", len(failures), len(results), strings.Join(failures, "; "))
+}
 
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return "", err
+// hostLimiter spaces out requests to the same host by handing out
+// reserved time slots. A nil limiter never waits.
+type hostLimiter struct {
+	delay time.Duration
+	mu    sync.Mutex
+	next  map[string]time.Time
+}
+
+func newHostLimiter(delay time.Duration) *hostLimiter {
+	return &hostLimiter{delay: delay, next: map[string]time.Time{}}
+}
+
+func (l *hostLimiter) wait(ctx context.Context, rawURL string) error {
+	if l == nil || l.delay <= 0 {
+		return ctx.Err()
+	}
+	host := rawURL
+	if u, err := url.Parse(rawURL); err == nil {
+		host = strings.ToLower(u.Host)
 	}
 
-	return string(body), nil
+	l.mu.Lock()
+	now := time.Now()
+	slot := l.next[host]
+	if slot.Before(now) {
+		slot = now
+	}
+	l.next[host] = slot.Add(l.delay)
+	l.mu.Unlock()
+
+	timer := time.NewTimer(time.Until(slot))
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
 }
 
+// CleanHTMLContent returns the readable main text of an HTML page.
 func CleanHTMLContent(html string) string {
-	doc, err := goquery.NewDocumentFromReader(strings.NewReader(html))
+	extracted, err := ExtractMainContent(html, "")
 	if err != nil {
-		return html // fallback
+		return strings.TrimSpace(html) // fallback
 	}
-
-	return strings.TrimSpace(doc.Text())
+	return extracted.Text
 }
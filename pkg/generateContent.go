@@ -1,189 +1,194 @@
 package pkg
 
 import (
-	"bytes"
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
-	"io/ioutil"
 	"log"
-	"net/http"
+	"reflect"
 	"strings"
+	"sync"
 
 	"github.com/iamlucif3r/sarjan/internal/types"
 )
 
-func GenerateContentIdeas(ctx context.Context, articles []types.JudgedArticle, Config types.Config) (types.ContentIdeas, error) {
-	var contextText string
-	var contentIdea types.ContentIdeas
-	for _, article := range articles {
-		contextText += fmt.Sprintf("- %s: %s\n", article.Title, article.Content)
-	}
-
-	prompt := fmt.Sprintf(`
-You are the voice behind *pwnspectrum* — a faceless, savage, unfiltered cybersecurity content brand that **owns timelines** and **commands respect** from hackers, red teamers, blue teamers, DevSecOps goons, and every script kiddie watching from the shadows.
-
-You’ve got no time for generic corporate cyber yapping. Your content is:
-- Loud where others whisper
-- Deep where others skim
-- Funny, brutal, and smart as hell
-- For LinkedIn: “Speak like you got laid off from a unicorn startup and now write like Naval.” Grounded in **practical, operational tactics** — share methods, frameworks, and war stories tied to the news.
-- For Reels: “Short, punchy, should slap harder than a 0-day on prod.” Visually engaging, instantly digestible, but hinting at a bigger play hackers will want to dig into.
-- For Twitter: “Roast vulnerabilities. Inject humor. Drop 1-liners like reverse shells.” Tactical, witty, and dripping with hacker culture references.
-
-You will:
-- Extract not just *what happened*, but the **real operational impact** for attackers and defenders.
-- Call out possible exploitation paths, detection methods, mitigation tips, or counter-tactics — while staying platform-specific.
-- Offer **multiple interpretations** of the same news so each platform gets its own angle.
-
-🔍 **Before writing anything, run this mental checklist**:
-1. **Attack Chain** — How could this be exploited end-to-end? What steps would an attacker take? What tooling or TTPs fit here?
-2. **Detection Gap** — How would most defenders miss this? Where are logging, monitoring, or response weaknesses?
-3. **Mitigation** — How could an org patch, detect, or harden against it *now* without waiting for a vendor fix?
-4. Translate those insights into platform-specific content without explicitly stating the checklist.
-
-Your job is to convert the following **high-signal cyber news** into content that SLAPS on:
-
-💣 YouTube | 🔪 Twitter | 🧠 LinkedIn | 🧨 Instagram
-
-News:
-%s
-
-Now generate ideas for each platform:
-
-🟥 YOUTUBE (2 videos):
-Each should include:
-- "title": Click-me-or-regret-it style (but no lies)
-- "hook": Killer intro line (edgy, sarcastic, or dramatic) that teases the tactical angle
-- "bullet_points": Story beats showing exploitation flow, real-world attack scenarios, or defense breakdowns
-
-🟦 TWITTER/X:
-- 5 banger tweets (mix humor + actionable takeaway — e.g., an exploit vector, detection tip, or TTP summary)
-- 1–2 threads:
-  - "title": Story/guide title with curiosity baked in
-  - "tweets": Drop a war story, a condensed exploit walkthrough, or “how to spot/fix” guide in 6 tweets or less — every tweet adds value
-
-🟩 LINKEDIN (1 post):
-- Tactical but framed for professionals
-- Tell a short, impactful story from the news with a hacker’s lens — highlight the exploitation chain, operational blind spots, and the lesson for defenders
-
-🟪 INSTAGRAM:
-- 2 REEL IDEAS:
-  - "idea": Visual hook (POV exploit moment, hacker POV, meme-worthy attack chain)
-  - "caption_style": meme | cinematic | sarcastic | educational — match to the operational angle
-- 2 POST CAPTIONS:
-  - 1–2 lines, either savage or surgical — must hit emotionally or technically
-
-📦 FORMAT:
-Only return **raw, valid JSON** in this exact structure:
-
-{
-  "linkedin_posts": ["string"],
-  "youtube_video_ideas": [
-    {
-      "title": "string",
-      "hook": "string",
-      "bullet_points": ["string", "string", "string"]
-    },
-    {
-      "title": "string",
-      "hook": "string",
-      "bullet_points": ["string", "string", "string"]
-    }
-  ],
-  "instagram_reels": [
-    {
-      "idea": "string",
-      "caption_style": "string"
-    },
-    {
-      "idea": "string",
-      "caption_style": "string"
-    }
-  ],
-  "instagram_posts": ["string", "string"],
-  "twitter_posts": ["string", "string", "string", "string", "string"],
-  "twitter_threads": [
-    {
-      "title": "string",
-      "tweets": ["string", "string", "string"]
-    }
-  ]
+// ContentOptions tunes a single GenerateContentIdeas call.
+type ContentOptions struct {
+	// MaxAttempts bounds how many times the model is re-prompted after
+	// returning output that fails to parse or validate.
+	MaxAttempts int
+	// Budget sizes the prompt and the model's context window. A zero
+	// value is derived from the client's model.
+	Budget TokenBudget
+	// Summarise lets oversized articles be summarised by the model
+	// instead of truncated.
+	Summarise bool
+	// Prompt is the content_ideas template to render. Nil uses the
+	// built-in version.
+	Prompt *PromptTemplate
+	// Brand is the persona to write as. A zero value uses pwnspectrum.
+	Brand types.BrandProfile
+	// Requirements are the item counts to generate per ContentIdeas
+	// field. Nil generates everything in ContentRequirements.
+	Requirements map[string]ItemCount
+	// Concurrency bounds how many platform prompts run at once.
+	Concurrency int
 }
 
-🧠 RULES:
-- Be bold. Be clever. Be ruthless with boring.
-- Focus on **practical, operational insights** — no vague “awareness” fluff.
-- Don’t just summarize — show how attackers would weaponize it, and how defenders can counter.
-- No markdown, no explanations, no code blocks — just raw JSON.
-- Content must read like it came from someone who lives in exploits, packets, and logs — not news headlines.
-
-Your goal: Content so tactical and savage it gets bookmarked by pentesters, banned in corporate Slack, and screenshot into threat intel decks without credit.
-`, contextText)
-
-	ollamaURL := Config.OllamaURL + "/api/generate"
+// ContentResult is the merged output of one generation run. Platforms that
+// failed are left empty in Ideas and reported in Errors.
+type ContentResult struct {
+	Ideas  types.ContentIdeas
+	Errors map[string]error
+}
 
-	payload := map[string]interface{}{
-		"model":       Config.OllamaModel,
-		"prompt":      prompt,
-		"temperature": 0.7,
-		"stream":      false,
+// GenerateContentIdeas prompts the model once per requested platform,
+// concurrently, and merges what succeeded. An error is returned only when
+// setup fails or every platform fails.
+func GenerateContentIdeas(ctx context.Context, client LLMClient, articles []types.JudgedArticle, opts ContentOptions) (ContentResult, error) {
+	result := ContentResult{Errors: map[string]error{}}
+	if opts.Budget.ContextWindow == 0 {
+		opts.Budget = BudgetForModel(client.Model(), 0, 0)
 	}
-
-	requestBody, err := json.Marshal(payload)
-	if err != nil {
-		return types.ContentIdeas{}, fmt.Errorf("failed to marshal payload: %w", err)
+	fitBudget := opts.Budget
+	if opts.MaxAttempts > 1 {
+		// Leave room for the rejected output echoed back in a repair prompt.
+		fitBudget.ReserveOutput *= 2
 	}
-
-	resp, err := http.Post(ollamaURL, "application/json", bytes.NewBuffer(requestBody))
-	if err != nil {
-		return contentIdea, fmt.Errorf("failed to call Ollama API: %v", err)
+	if opts.MaxAttempts < 1 {
+		opts.MaxAttempts = 1
 	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != 200 {
-		bodyBytes, _ := ioutil.ReadAll(resp.Body)
-		return contentIdea, fmt.Errorf("non-200 response: %d %s", resp.StatusCode, string(bodyBytes))
+	if opts.Concurrency < 1 {
+		opts.Concurrency = 1
 	}
-
-	respBody, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return contentIdea, fmt.Errorf("failed to read response body: %v", err)
+	if opts.Requirements == nil {
+		opts.Requirements = ContentRequirements
 	}
 
-	type ollamaResponse struct {
-		Response string `json:"response"`
+	if opts.Prompt == nil {
+		registry, err := NewPromptRegistry("", nil)
+		if err != nil {
+			return result, err
+		}
+		if opts.Prompt, err = registry.Get(PromptContentIdeas); err != nil {
+			return result, err
+		}
 	}
-
-	// fmt.Println("RAW OLLAMA:", string(respBody))
-
-	var ollamaResp ollamaResponse
-	if err := json.Unmarshal(respBody, &ollamaResp); err != nil {
-		return types.ContentIdeas{}, fmt.Errorf("failed to parse Ollama response: %w", err)
+	if opts.Brand.Name == "" {
+		opts.Brand = DefaultBrandProfile()
 	}
-	fmt.Println("OLLAMA RESPONSE:", ollamaResp.Response)
-	cleanOutput := strings.TrimSpace(ollamaResp.Response)
-	if strings.HasPrefix(cleanOutput, "```") {
-		cleanOutput = strings.TrimPrefix(cleanOutput, "```json")
-		cleanOutput = strings.TrimPrefix(cleanOutput, "```")
-		cleanOutput = strings.TrimSuffix(cleanOutput, "```")
-		cleanOutput = strings.TrimSpace(cleanOutput)
+	data := PromptData{Brand: opts.Brand, Counts: opts.Requirements, Format: FormatExample(opts.Requirements)}
+
+	// Render once with every requested platform and no article bodies to
+	// learn what the template itself costs, then fit the bodies into what
+	// is left. Each platform prompt is smaller, so one fit serves them all.
+	data.Articles = make([]types.JudgedArticle, len(articles))
+	for i, a := range articles {
+		data.Articles[i] = a
+		data.Articles[i].Content = ""
+	}
+	skeleton, err := opts.Prompt.Render(data)
+	if err != nil {
+		return result, err
 	}
+	builder := PromptBuilder{Budget: fitBudget, Client: client, Summarise: opts.Summarise}
+	data.Articles = builder.FitArticles(ctx, opts.Budget.Estimate(skeleton), articles)
+
+	platforms, split := requirementsByPlatform(opts.Requirements)
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, opts.Concurrency)
+	for _, platform := range platforms {
+		wg.Add(1)
+		go func(platform string, reqs map[string]ItemCount) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			platformData := data
+			platformData.Counts = reqs
+			platformData.Format = FormatExample(reqs)
+			ideas, err := generatePlatform(ctx, client, opts, platformData)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				log.Printf("[WARN] Generating %s content failed: %v", platform, err)
+				result.Errors[platform] = err
+				return
+			}
+			copyContentFields(&result.Ideas, ideas, reqs)
+		}(platform, split[platform])
+	}
+	wg.Wait()
+
+	if len(result.Errors) == len(platforms) {
+		errs := make([]error, 0, len(platforms))
+		for _, platform := range platforms {
+			errs = append(errs, fmt.Errorf("%s: %w", platform, result.Errors[platform]))
+		}
+		return result, errors.Join(errs...)
+	}
+	return result, nil
+}
 
-	fmt.Println("CLEANED OLLAMA OUTPUT:", cleanOutput)
-	var tmp map[string]any
-	if err := json.Unmarshal([]byte(cleanOutput), &tmp); err != nil {
-		log.Fatal("Invalid JSON structure:", err)
+// generatePlatform renders the prompt for one platform's fields and runs
+// the generate/validate/repair loop until the output is acceptable.
+func generatePlatform(ctx context.Context, client LLMClient, opts ContentOptions, data PromptData) (types.ContentIdeas, error) {
+	var contentIdea types.ContentIdeas
+	prompt, err := opts.Prompt.Render(data)
+	if err != nil {
+		return contentIdea, err
 	}
-	for k := range tmp {
-		log.Println("[DEBUG] Key in JSON:", k)
+	schema := ContentIdeasSchema(data.Counts)
+	genErr := &ContentGenerationError{}
+	request := prompt
+	for attempt := 1; attempt <= opts.MaxAttempts; attempt++ {
+		output, err := client.GenerateJSON(ctx, request, schema, GenerateOptions{Temperature: Temperature(0.7), NumCtx: opts.Budget.ContextWindow})
+		if err != nil {
+			return contentIdea, fmt.Errorf("failed to call LLM: %w", err)
+		}
+
+		genErr.Attempts = attempt
+		genErr.LastOutput = output
+		ideas, violations := CheckContentOutput(output, data.Counts)
+		genErr.Violations = violations
+		if len(genErr.Violations) == 0 {
+			return ideas, nil
+		}
+
+		log.Printf("[WARN] Attempt %d/%d produced invalid content: %s", attempt, opts.MaxAttempts, strings.Join(genErr.Violations, "; "))
+		request = repairPrompt(prompt, output, genErr.Violations)
 	}
 
-	var ideas types.ContentIdeas
-	if err := json.Unmarshal([]byte(cleanOutput), &ideas); err != nil {
-		log.Println("[ERROR] Failed to parse output:", err)
-		return types.ContentIdeas{}, fmt.Errorf("failed to parse model JSON output: %w", err)
+	return contentIdea, genErr
+}
+
+// copyContentFields copies the ContentIdeas fields named in reqs from src
+// into dst, matching on json tags.
+func copyContentFields(dst *types.ContentIdeas, src types.ContentIdeas, reqs map[string]ItemCount) {
+	d := reflect.ValueOf(dst).Elem()
+	s := reflect.ValueOf(src)
+	for i := 0; i < d.NumField(); i++ {
+		name := strings.Split(d.Type().Field(i).Tag.Get("json"), ",")[0]
+		if _, ok := reqs[name]; ok {
+			d.Field(i).Set(s.Field(i))
+		}
 	}
+}
 
-	return ideas, nil
+// repairPrompt re-sends the original instructions together with the
+// rejected output and the reasons it was rejected.
+func repairPrompt(prompt, output string, violations []string) string {
+	var b strings.Builder
+	b.WriteString(prompt)
+	b.WriteString("\n\nYour previous response was rejected:\n")
+	b.WriteString(output)
+	b.WriteString("\n\nFix these problems and return the complete corrected JSON only:\n")
+	for _, v := range violations {
+		b.WriteString("- ")
+		b.WriteString(v)
+		b.WriteString("\n")
+	}
+	return b.String()
 }
@@ -0,0 +1,137 @@
+package pkg
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/iamlucif3r/sarjan/internal/types"
+)
+
+// Ready checks every dependency a job needs, concurrently and each within
+// Config.HealthCheckTimeout: the database, the LLM backend and its model,
+// that each brand's output directory is writable, and that each brand's
+// notifiers can be built.
+func (a *App) Ready(ctx context.Context) types.HealthReport {
+	checks := map[string]func(context.Context) (string, error){
+		"database":    a.checkDatabase,
+		"llm":         a.checkLLM,
+		"output_dirs": a.checkOutputDirs,
+		"notifiers":   a.checkNotifiers,
+	}
+	timeout := a.Config.HealthCheckTimeout
+	if timeout <= 0 {
+		timeout = 3 * time.Second
+	}
+
+	report := types.HealthReport{Status: types.HealthOK, CheckedAt: time.Now(), Checks: map[string]types.HealthCheck{}}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for name, check := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(ctx, timeout)
+			defer cancel()
+			start := time.Now()
+			detail, err := check(ctx)
+			result := types.HealthCheck{Status: types.HealthOK, LatencyMS: time.Since(start).Milliseconds(), Detail: detail}
+			if err != nil {
+				result.Status, result.Error = types.HealthFail, err.Error()
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			report.Checks[name] = result
+			if err != nil {
+				report.Status = types.HealthFail
+			}
+		}()
+	}
+	wg.Wait()
+	return report
+}
+
+func (a *App) checkDatabase(ctx context.Context) (string, error) {
+	if a.Store == nil {
+		return "", errors.New("no database configured")
+	}
+	if err := a.Store.Ping(ctx); err != nil {
+		return "", err
+	}
+	stats := a.Store.Stats()
+	return fmt.Sprintf("%s: %d open, %d in use, %d idle", a.Store.Driver(), stats.OpenConnections, stats.InUse, stats.Idle), nil
+}
+
+func (a *App) checkLLM(ctx context.Context) (string, error) {
+	if a.LLM == nil {
+		return "", errors.New("no LLM client configured")
+	}
+	checker, ok := a.LLM.(ModelChecker)
+	if !ok {
+		return "model " + a.LLM.Model() + " not checked", nil
+	}
+	if err := checker.CheckModel(ctx); err != nil {
+		return "", err
+	}
+	return "model " + a.LLM.Model() + " available", nil
+}
+
+// checkOutputDirs creates and removes a file in every brand's output
+// directory.
+func (a *App) checkOutputDirs(context.Context) (string, error) {
+	if a.Brands == nil {
+		return "", errors.New("no brands loaded")
+	}
+	var dirs, problems []string
+	seen := map[string]bool{}
+	for _, brand := range a.Brands.List() {
+		if seen[brand.OutputDir] {
+			continue
+		}
+		seen[brand.OutputDir] = true
+		dirs = append(dirs, brand.OutputDir)
+		if err := os.MkdirAll(brand.OutputDir, 0755); err != nil {
+			problems = append(problems, err.Error())
+			continue
+		}
+		f, err := os.CreateTemp(brand.OutputDir, ".ready-*")
+		if err != nil {
+			problems = append(problems, err.Error())
+			continue
+		}
+		f.Close()
+		os.Remove(f.Name())
+	}
+	if len(problems) > 0 {
+		return "", errors.New(strings.Join(problems, "; "))
+	}
+	return strings.Join(dirs, ", ") + " writable", nil
+}
+
+// checkNotifiers builds every brand's sinks, which catches missing
+// settings such as SMTP_HOST or an unset ${VAR}, without sending anything.
+func (a *App) checkNotifiers(context.Context) (string, error) {
+	if a.Brands == nil {
+		return "", errors.New("no brands loaded")
+	}
+	var problems []string
+	total := 0
+	for _, brand := range a.Brands.List() {
+		notifiers, err := a.Notifiers(brand, a.Webhooks)
+		switch {
+		case err != nil:
+			problems = append(problems, fmt.Sprintf("brand %s: %v", brand.ID, err))
+		case len(notifiers) == 0:
+			problems = append(problems, fmt.Sprintf("brand %s has no delivery sinks configured", brand.ID))
+		}
+		total += len(notifiers)
+	}
+	if len(problems) > 0 {
+		return "", errors.New(strings.Join(problems, "; "))
+	}
+	return fmt.Sprintf("%d sink(s) across %d brand(s)", total, len(a.Brands.List())), nil
+}
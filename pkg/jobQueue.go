@@ -0,0 +1,92 @@
+package pkg
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/iamlucif3r/sarjan/internal/types"
+)
+
+// JobQueue hands persisted job IDs to a fixed set of background workers.
+// The jobs table is the source of truth; the channel only carries IDs.
+type JobQueue struct {
+	app *App
+	ids chan int64
+}
+
+func NewJobQueue(app *App, size int) *JobQueue {
+	return &JobQueue{
+		app: app,
+		ids: make(chan int64, size),
+	}
+}
+
+func (q *JobQueue) Start(ctx context.Context, workers int) {
+	for i := 0; i < workers; i++ {
+		go func() {
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case id := <-q.ids:
+					job, err := q.app.Store.GetJob(id)
+					if err != nil {
+						log.Printf("[ERROR] Failed to load job %d: %v", id, err)
+						continue
+					}
+					_ = q.app.RunGenerateJob(ctx, job)
+				}
+			}
+		}()
+	}
+}
+
+func (q *JobQueue) Enqueue(id int64) error {
+	select {
+	case q.ids <- id:
+		return nil
+	default:
+		return fmt.Errorf("job queue is full")
+	}
+}
+
+// Submit persists a new job and schedules it.
+func (q *JobQueue) Submit(req types.GenerateRequest) (types.Job, error) {
+	job, err := q.app.Store.CreateJob(req)
+	if err != nil {
+		return job, err
+	}
+	if err := q.Enqueue(job.ID); err != nil {
+		_ = q.app.Store.SetJobStageError(job.ID, types.JobQueued, err.Error())
+		_ = q.app.Store.SetJobState(job.ID, types.JobFailed)
+		return job, err
+	}
+	return job, nil
+}
+
+// Resume re-schedules jobs still queued from a previous run and fails the
+// ones that were mid-flight when the process stopped.
+func (q *JobQueue) Resume() error {
+	interrupted, err := q.app.Store.ListJobsInState(
+		types.JobFetching, types.JobRanking, types.JobEnriching, types.JobGenerating, types.JobRendering, types.JobDelivering)
+	if err != nil {
+		return err
+	}
+	for _, job := range interrupted {
+		_ = q.app.Store.SetJobStageError(job.ID, job.State, "interrupted by restart")
+		_ = q.app.Store.SetJobState(job.ID, types.JobFailed)
+	}
+
+	queued, err := q.app.Store.ListJobsInState(types.JobQueued)
+	if err != nil {
+		return err
+	}
+	go func() {
+		for _, job := range queued {
+			q.ids <- job.ID
+		}
+	}()
+	log.Printf("[INFO] Resumed %d queued jobs, failed %d interrupted jobs", len(queued), len(interrupted))
+	return nil
+}
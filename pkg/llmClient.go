@@ -0,0 +1,67 @@
+package pkg
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/iamlucif3r/sarjan/internal/types"
+)
+
+// GenerateOptions carries per-call sampling settings. Zero values leave the
+// backend's defaults in place.
+type GenerateOptions struct {
+	Temperature *float64
+	// NumCtx sets the context window for backends that size it per
+	// request, such as Ollama's num_ctx.
+	NumCtx int
+}
+
+// LLMClient is the single entry point SARJAN's prompting code uses to talk
+// to a model, so backends can be swapped and tests can inject a fake.
+type LLMClient interface {
+	// Model reports the model name requests are sent to.
+	Model() string
+	// Generate returns the full completion for prompt.
+	Generate(ctx context.Context, prompt string, opts GenerateOptions) (string, error)
+	// GenerateJSON asks the backend to constrain output to JSON. When schema
+	// is non-nil it is passed through as a JSON schema.
+	GenerateJSON(ctx context.Context, prompt string, schema any, opts GenerateOptions) (string, error)
+	// Stream calls onChunk with each piece of the completion as it arrives.
+	Stream(ctx context.Context, prompt string, opts GenerateOptions, onChunk func(string) error) error
+}
+
+// ModelChecker is implemented by clients that can confirm their backend
+// is reachable and serves the configured model without generating.
+type ModelChecker interface {
+	CheckModel(ctx context.Context) error
+}
+
+func Temperature(t float64) *float64 {
+	return &t
+}
+
+// NewLLMClient builds the client selected by Config.LLMProvider.
+func NewLLMClient(Config types.Config) (LLMClient, error) {
+	switch strings.ToLower(Config.LLMProvider) {
+	case "", "ollama":
+		return NewOllamaClient(Config.LLMBaseURL, Config.OllamaModel, Config.LLMTimeout), nil
+	case "openai":
+		return NewOpenAIClient(Config.LLMBaseURL, Config.LLMAPIKey, Config.OllamaModel, Config.LLMTimeout), nil
+	default:
+		return nil, fmt.Errorf("unknown LLM provider %q", Config.LLMProvider)
+	}
+}
+
+// stripCodeFences removes a surrounding ``` or ```json block, which models
+// add even when told not to.
+func stripCodeFences(s string) string {
+	s = strings.TrimSpace(s)
+	if strings.HasPrefix(s, "```") {
+		s = strings.TrimPrefix(s, "```json")
+		s = strings.TrimPrefix(s, "```")
+		s = strings.TrimSuffix(s, "```")
+		s = strings.TrimSpace(s)
+	}
+	return s
+}
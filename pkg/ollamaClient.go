@@ -0,0 +1,186 @@
+package pkg
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// OllamaClient talks to Ollama's native /api/generate endpoint.
+type OllamaClient struct {
+	BaseURL    string
+	ModelName  string
+	HTTPClient *http.Client
+}
+
+// NewOllamaClient builds a client whose requests give up after timeout;
+// zero waits forever.
+func NewOllamaClient(baseURL, model string, timeout time.Duration) *OllamaClient {
+	return &OllamaClient{
+		BaseURL:    strings.TrimRight(baseURL, "/"),
+		ModelName:  model,
+		HTTPClient: &http.Client{Timeout: timeout},
+	}
+}
+
+type ollamaResponse struct {
+	Response string `json:"response"`
+	Done     bool   `json:"done"`
+	Error    string `json:"error"`
+}
+
+func (c *OllamaClient) Model() string {
+	return c.ModelName
+}
+
+func (c *OllamaClient) payload(prompt string, opts GenerateOptions, stream bool) map[string]any {
+	payload := map[string]any{
+		"model":  c.ModelName,
+		"prompt": prompt,
+		"stream": stream,
+	}
+	options := map[string]any{}
+	if opts.Temperature != nil {
+		options["temperature"] = *opts.Temperature
+	}
+	if opts.NumCtx > 0 {
+		options["num_ctx"] = opts.NumCtx
+	}
+	if len(options) > 0 {
+		payload["options"] = options
+	}
+	return payload
+}
+
+func (c *OllamaClient) post(ctx context.Context, payload map[string]any) (*http.Response, error) {
+	requestBody, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal payload: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.BaseURL+"/api/generate", bytes.NewReader(requestBody))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to call Ollama API: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("non-200 response: %d %s", resp.StatusCode, string(bodyBytes))
+	}
+	return resp, nil
+}
+
+func (c *OllamaClient) complete(ctx context.Context, payload map[string]any) (string, error) {
+	resp, err := c.post(ctx, payload)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	var out ollamaResponse
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return "", fmt.Errorf("failed to parse Ollama response: %w", err)
+	}
+	if out.Error != "" {
+		return "", fmt.Errorf("ollama error: %s", out.Error)
+	}
+	return out.Response, nil
+}
+
+func (c *OllamaClient) Generate(ctx context.Context, prompt string, opts GenerateOptions) (string, error) {
+	return c.complete(ctx, c.payload(prompt, opts, false))
+}
+
+func (c *OllamaClient) GenerateJSON(ctx context.Context, prompt string, schema any, opts GenerateOptions) (string, error) {
+	payload := c.payload(prompt, opts, false)
+	if schema != nil {
+		payload["format"] = schema
+	} else {
+		payload["format"] = "json"
+	}
+	out, err := c.complete(ctx, payload)
+	if err != nil {
+		return "", err
+	}
+	return stripCodeFences(out), nil
+}
+
+func (c *OllamaClient) Stream(ctx context.Context, prompt string, opts GenerateOptions, onChunk func(string) error) error {
+	resp, err := c.post(ctx, c.payload(prompt, opts, true))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		var chunk ollamaResponse
+		if err := json.Unmarshal(line, &chunk); err != nil {
+			return fmt.Errorf("failed to parse stream chunk: %w", err)
+		}
+		if chunk.Error != "" {
+			return fmt.Errorf("ollama error: %s", chunk.Error)
+		}
+		if chunk.Response != "" {
+			if err := onChunk(chunk.Response); err != nil {
+				return err
+			}
+		}
+		if chunk.Done {
+			return nil
+		}
+	}
+	return scanner.Err()
+}
+
+// CheckModel lists the installed models via /api/tags and fails when the
+// configured one is missing. A name without a tag matches ":latest".
+func (c *OllamaClient) CheckModel(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.BaseURL+"/api/tags", nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to call Ollama API: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("non-200 response from /api/tags: %d", resp.StatusCode)
+	}
+	var tags struct {
+		Models []struct {
+			Name  string `json:"name"`
+			Model string `json:"model"`
+		} `json:"models"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&tags); err != nil {
+		return fmt.Errorf("failed to parse Ollama tags: %w", err)
+	}
+	want := c.ModelName
+	if !strings.Contains(want, ":") {
+		want += ":latest"
+	}
+	for _, m := range tags.Models {
+		if m.Name == c.ModelName || m.Name == want || m.Model == want {
+			return nil
+		}
+	}
+	return fmt.Errorf("model %s is not installed in Ollama (run `ollama pull %s`)", c.ModelName, c.ModelName)
+}
@@ -0,0 +1,209 @@
+package pkg
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// OpenAIClient talks to any server exposing the OpenAI chat-completions
+// API, which covers llama.cpp server, vLLM and LM Studio. BaseURL should
+// include the version prefix, e.g. http://localhost:8080/v1.
+type OpenAIClient struct {
+	BaseURL    string
+	APIKey     string
+	ModelName  string
+	HTTPClient *http.Client
+}
+
+// NewOpenAIClient builds a client whose requests give up after timeout;
+// zero waits forever.
+func NewOpenAIClient(baseURL, apiKey, model string, timeout time.Duration) *OpenAIClient {
+	return &OpenAIClient{
+		BaseURL:    strings.TrimRight(baseURL, "/"),
+		APIKey:     apiKey,
+		ModelName:  model,
+		HTTPClient: &http.Client{Timeout: timeout},
+	}
+}
+
+type openAIChoice struct {
+	Message struct {
+		Content string `json:"content"`
+	} `json:"message"`
+	Delta struct {
+		Content string `json:"content"`
+	} `json:"delta"`
+}
+
+type openAIResponse struct {
+	Choices []openAIChoice `json:"choices"`
+	Error   *struct {
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+func (c *OpenAIClient) Model() string {
+	return c.ModelName
+}
+
+func (c *OpenAIClient) payload(prompt string, opts GenerateOptions, stream bool) map[string]any {
+	payload := map[string]any{
+		"model": c.ModelName,
+		"messages": []map[string]string{
+			{"role": "user", "content": prompt},
+		},
+		"stream": stream,
+	}
+	if opts.Temperature != nil {
+		payload["temperature"] = *opts.Temperature
+	}
+	return payload
+}
+
+func (c *OpenAIClient) post(ctx context.Context, payload map[string]any) (*http.Response, error) {
+	requestBody, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal payload: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.BaseURL+"/chat/completions", bytes.NewReader(requestBody))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if c.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.APIKey)
+	}
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to call chat completions API: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("non-200 response: %d %s", resp.StatusCode, string(bodyBytes))
+	}
+	return resp, nil
+}
+
+func (c *OpenAIClient) complete(ctx context.Context, payload map[string]any) (string, error) {
+	resp, err := c.post(ctx, payload)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	var out openAIResponse
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return "", fmt.Errorf("failed to parse chat completions response: %w", err)
+	}
+	if out.Error != nil {
+		return "", fmt.Errorf("chat completions error: %s", out.Error.Message)
+	}
+	if len(out.Choices) == 0 {
+		return "", fmt.Errorf("chat completions response has no choices")
+	}
+	return out.Choices[0].Message.Content, nil
+}
+
+func (c *OpenAIClient) Generate(ctx context.Context, prompt string, opts GenerateOptions) (string, error) {
+	return c.complete(ctx, c.payload(prompt, opts, false))
+}
+
+func (c *OpenAIClient) GenerateJSON(ctx context.Context, prompt string, schema any, opts GenerateOptions) (string, error) {
+	payload := c.payload(prompt, opts, false)
+	if schema != nil {
+		payload["response_format"] = map[string]any{
+			"type": "json_schema",
+			"json_schema": map[string]any{
+				"name":   "output",
+				"schema": schema,
+			},
+		}
+	} else {
+		payload["response_format"] = map[string]string{"type": "json_object"}
+	}
+	out, err := c.complete(ctx, payload)
+	if err != nil {
+		return "", err
+	}
+	return stripCodeFences(out), nil
+}
+
+func (c *OpenAIClient) Stream(ctx context.Context, prompt string, opts GenerateOptions, onChunk func(string) error) error {
+	resp, err := c.post(ctx, c.payload(prompt, opts, true))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if !strings.HasPrefix(line, "data:") {
+			continue
+		}
+		data := strings.TrimSpace(strings.TrimPrefix(line, "data:"))
+		if data == "[DONE]" {
+			return nil
+		}
+		var chunk openAIResponse
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			return fmt.Errorf("failed to parse stream chunk: %w", err)
+		}
+		if chunk.Error != nil {
+			return fmt.Errorf("chat completions error: %s", chunk.Error.Message)
+		}
+		for _, choice := range chunk.Choices {
+			if choice.Delta.Content == "" {
+				continue
+			}
+			if err := onChunk(choice.Delta.Content); err != nil {
+				return err
+			}
+		}
+	}
+	return scanner.Err()
+}
+
+// CheckModel fails when the server's /models list does not include the
+// configured model.
+func (c *OpenAIClient) CheckModel(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.BaseURL+"/models", nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	if c.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.APIKey)
+	}
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to call models API: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("non-200 response from /models: %d", resp.StatusCode)
+	}
+	var models struct {
+		Data []struct {
+			ID string `json:"id"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&models); err != nil {
+		return fmt.Errorf("failed to parse models response: %w", err)
+	}
+	for _, m := range models.Data {
+		if m.ID == c.ModelName {
+			return nil
+		}
+	}
+	return fmt.Errorf("model %s is not served by %s", c.ModelName, c.BaseURL)
+}
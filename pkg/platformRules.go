@@ -0,0 +1,332 @@
+package pkg
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"reflect"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/iamlucif3r/sarjan/internal/types"
+)
+
+// TextLimits are the rules one kind of generated text must follow. Zero
+// disables a rule.
+type TextLimits struct {
+	// MaxChars caps the length in characters, or in X's weighted units
+	// when Weighted is set.
+	MaxChars int
+	Weighted bool
+	// MaxHashtags caps how many #tags the text may carry.
+	MaxHashtags int
+	// MaxLines caps the number of non-blank lines, counted only in the
+	// first PreviewChars characters when that is set.
+	MaxLines     int
+	PreviewChars int
+	// BreakAfter requires at least one line break in text longer than
+	// this many characters, so long posts are not a single wall of text.
+	BreakAfter int
+}
+
+// Instagram captions may run to many paragraphs; the lines rule is a
+// preview rule, keeping the roughly 125 characters shown in the feed to a
+// few lines.
+var (
+	tweetLimits         = TextLimits{MaxChars: 280, Weighted: true, MaxHashtags: 3}
+	linkedinLimits      = TextLimits{MaxChars: 3000, MaxHashtags: 5, BreakAfter: 600}
+	instagramPostLimits = TextLimits{MaxChars: 2200, MaxHashtags: 30, MaxLines: 3, PreviewChars: 125}
+	youtubeTitleLimits  = TextLimits{MaxChars: 100}
+)
+
+// MaxThreadTweets is the most tweets a generated thread may have.
+const MaxThreadTweets = 6
+
+// tcoLength is what X counts every link as after wrapping it in t.co.
+const tcoLength = 23
+
+var (
+	urlPattern     = regexp.MustCompile(`https?://\S+`)
+	hashtagPattern = regexp.MustCompile(`(?:^|\s)#[\p{L}\p{N}_]+`)
+)
+
+// TweetLength counts text the way X does: every URL is 23 characters and
+// characters outside the Latin and general punctuation ranges, including
+// CJK and emoji, count double. An emoji sequence counts as one emoji, so
+// joiners, variation selectors, skin tones and tag characters add nothing,
+// nor does whatever a joiner attaches or the second half of a flag.
+func TweetLength(text string) int {
+	n := 0
+	rest := urlPattern.ReplaceAllStringFunc(text, func(string) string {
+		n += tcoLength
+		return ""
+	})
+	joined, flagOpen := false, false
+	for _, r := range rest {
+		attached := joined
+		joined = r == 0x200D
+		regional := r >= 0x1F1E6 && r <= 0x1F1FF
+		switch {
+		case attached, joined, r == 0xFE0E, r == 0xFE0F, r >= 0x1F3FB && r <= 0x1F3FF, r >= 0xE0020 && r <= 0xE007F:
+		case regional && flagOpen:
+			flagOpen = false
+		case r <= 0x10FF, r >= 0x2000 && r <= 0x200C, r >= 0x2010 && r <= 0x201F, r >= 0x2032 && r <= 0x2037:
+			n++
+		default:
+			n += 2
+			flagOpen = regional
+		}
+		if !regional {
+			flagOpen = false
+		}
+	}
+	return n
+}
+
+// ValidatePlatformRules checks every generated item against its platform's
+// limits and the brand's banned phrases.
+func ValidatePlatformRules(ideas types.ContentIdeas, brand types.BrandProfile) []types.ContentFlag {
+	var flags []types.ContentFlag
+	banned := bannedPhrasePatterns(brand.BannedWords)
+	text := func(field string, index int, path, value string, limits TextLimits) {
+		flag := func(rule, format string, args ...any) {
+			flags = append(flags, types.ContentFlag{Field: field, Index: index, Path: path, Rule: rule, Message: fmt.Sprintf(format, args...)})
+		}
+		if limits.MaxChars > 0 {
+			n := utf8.RuneCountInString(value)
+			if limits.Weighted {
+				n = TweetLength(value)
+			}
+			if n > limits.MaxChars {
+				flag("length", "is %d characters, limit is %d", n, limits.MaxChars)
+			}
+		}
+		if limits.MaxHashtags > 0 {
+			if n := len(hashtagPattern.FindAllString(value, -1)); n > limits.MaxHashtags {
+				flag("hashtags", "has %d hashtags, limit is %d", n, limits.MaxHashtags)
+			}
+		}
+		if limits.MaxLines > 0 {
+			preview := value
+			if r := []rune(value); limits.PreviewChars > 0 && len(r) > limits.PreviewChars {
+				preview = string(r[:limits.PreviewChars])
+			}
+			if n := countLines(preview); n > limits.MaxLines {
+				if limits.PreviewChars > 0 {
+					flag("lines", "has %d lines in its first %d characters, limit is %d", n, limits.PreviewChars, limits.MaxLines)
+				} else {
+					flag("lines", "has %d lines, limit is %d", n, limits.MaxLines)
+				}
+			}
+		}
+		if limits.BreakAfter > 0 && utf8.RuneCountInString(value) > limits.BreakAfter && !strings.Contains(strings.TrimSpace(value), "\n") {
+			flag("line_breaks", "is a single block of text; break it into paragraphs")
+		}
+		for _, b := range banned {
+			if b.pattern.MatchString(value) {
+				flag("banned_phrase", "uses the banned phrase %q", b.phrase)
+			}
+		}
+	}
+
+	for i, v := range ideas.YouTubeVideoIdeas {
+		path := fmt.Sprintf("youtube_video_ideas[%d]", i)
+		text("youtube_video_ideas", i, path+".title", v.Title, youtubeTitleLimits)
+		text("youtube_video_ideas", i, path+".hook", v.Hook, TextLimits{})
+		for j, b := range v.BulletPoints {
+			text("youtube_video_ideas", i, fmt.Sprintf("%s.bullet_points[%d]", path, j), b, TextLimits{})
+		}
+	}
+	for i, t := range ideas.TwitterPosts {
+		text("twitter_posts", i, fmt.Sprintf("twitter_posts[%d]", i), t, tweetLimits)
+	}
+	for i, t := range ideas.TwitterThreads {
+		path := fmt.Sprintf("twitter_threads[%d]", i)
+		if len(t.Tweets) > MaxThreadTweets {
+			flags = append(flags, types.ContentFlag{Field: "twitter_threads", Index: i, Path: path + ".tweets", Rule: "thread_length",
+				Message: fmt.Sprintf("has %d tweets, limit is %d", len(t.Tweets), MaxThreadTweets)})
+		}
+		text("twitter_threads", i, path+".title", t.Title, TextLimits{})
+		for j, tweet := range t.Tweets {
+			text("twitter_threads", i, fmt.Sprintf("%s.tweets[%d]", path, j), tweet, tweetLimits)
+		}
+	}
+	for i, p := range ideas.LinkedInPosts {
+		text("linkedin_posts", i, fmt.Sprintf("linkedin_posts[%d]", i), p, linkedinLimits)
+	}
+	for i, r := range ideas.InstagramReels {
+		path := fmt.Sprintf("instagram_reels[%d]", i)
+		text("instagram_reels", i, path+".idea", r.Idea, TextLimits{})
+		text("instagram_reels", i, path+".caption_style", r.CaptionStyle, TextLimits{})
+	}
+	for i, p := range ideas.InstagramPosts {
+		text("instagram_posts", i, fmt.Sprintf("instagram_posts[%d]", i), p, instagramPostLimits)
+	}
+	return flags
+}
+
+func countLines(s string) int {
+	n := 0
+	for _, line := range strings.Split(s, "\n") {
+		if strings.TrimSpace(line) != "" {
+			n++
+		}
+	}
+	return n
+}
+
+type bannedPhrase struct {
+	phrase  string
+	pattern *regexp.Regexp
+}
+
+// bannedPhrasePatterns matches each phrase case-insensitively, anchored on
+// word boundaries where the phrase starts or ends with a word character.
+func bannedPhrasePatterns(phrases []string) []bannedPhrase {
+	var patterns []bannedPhrase
+	word := regexp.MustCompile(`^\w|\w$`)
+	for _, phrase := range phrases {
+		phrase = strings.TrimSpace(phrase)
+		if phrase == "" {
+			continue
+		}
+		expr := regexp.QuoteMeta(phrase)
+		if m := word.FindAllStringIndex(phrase, -1); len(m) > 0 {
+			if m[0][0] == 0 {
+				expr = `\b` + expr
+			}
+			if m[len(m)-1][1] == len(phrase) {
+				expr += `\b`
+			}
+		}
+		patterns = append(patterns, bannedPhrase{phrase, regexp.MustCompile(`(?i)` + expr)})
+	}
+	return patterns
+}
+
+// FixPlatformViolations asks the model to rewrite each flagged item, up to
+// attempts times, and returns the flags that remain. A rewrite that breaks
+// the content contract is discarded and the item left as it was.
+func FixPlatformViolations(ctx context.Context, client LLMClient, prompt *PromptTemplate, ideas *types.ContentIdeas, brand types.BrandProfile, flags []types.ContentFlag, attempts int) []types.ContentFlag {
+	if attempts < 1 {
+		attempts = 1
+	}
+	type itemKey struct {
+		field string
+		index int
+	}
+	var order []itemKey
+	byItem := map[itemKey][]types.ContentFlag{}
+	for _, f := range flags {
+		key := itemKey{f.Field, f.Index}
+		if _, ok := byItem[key]; !ok {
+			order = append(order, key)
+		}
+		byItem[key] = append(byItem[key], f)
+	}
+
+	var remaining []types.ContentFlag
+	for _, key := range order {
+		item, elemSchema, ok := contentItem(ideas, key.field, key.index)
+		if !ok {
+			remaining = append(remaining, byItem[key]...)
+			continue
+		}
+		current := byItem[key]
+		for attempt := 1; attempt <= attempts && len(current) > 0; attempt++ {
+			if err := rewriteItem(ctx, client, prompt, brand, key.field, item, elemSchema, current); err != nil {
+				log.Printf("[WARN] Rewriting %s[%d] failed: %v", key.field, key.index, err)
+				break
+			}
+			current = itemFlags(ValidatePlatformRules(*ideas, brand), key.field, key.index)
+		}
+		remaining = append(remaining, current...)
+	}
+	return remaining
+}
+
+// rewriteItem sends one item and its problems to the model and replaces the
+// item with the rewrite if it still satisfies the content contract.
+func rewriteItem(ctx context.Context, client LLMClient, prompt *PromptTemplate, brand types.BrandProfile, field string, item reflect.Value, elemSchema map[string]any, flags []types.ContentFlag) error {
+	encoded, err := json.MarshalIndent(item.Interface(), "", "  ")
+	if err != nil {
+		return err
+	}
+	problems := make([]string, len(flags))
+	for i, f := range flags {
+		problems[i] = f.Path + " " + f.Message
+	}
+	request, err := prompt.Render(PromptData{
+		Brand:    brand,
+		Platform: types.PlatformOf(field),
+		Item:     string(encoded),
+		Problems: problems,
+	})
+	if err != nil {
+		return err
+	}
+	schema := map[string]any{
+		"type":                 "object",
+		"properties":           map[string]any{"item": elemSchema},
+		"required":             []string{"item"},
+		"additionalProperties": false,
+	}
+	output, err := client.GenerateJSON(ctx, request, schema, GenerateOptions{Temperature: Temperature(0.4)})
+	if err != nil {
+		return fmt.Errorf("failed to call LLM: %w", err)
+	}
+	raw, err := repairJSONOutput(output)
+	if err != nil {
+		return err
+	}
+	var doc any
+	if err := json.Unmarshal(raw, &doc); err != nil {
+		return err
+	}
+	var violations []string
+	checkContract("", doc, schema, &violations)
+	if len(violations) > 0 {
+		return fmt.Errorf("rewrite rejected: %s", strings.Join(violations, "; "))
+	}
+	var wrapped struct {
+		Item json.RawMessage `json:"item"`
+	}
+	if err := json.Unmarshal(raw, &wrapped); err != nil {
+		return err
+	}
+	rewritten := reflect.New(item.Type())
+	if err := json.Unmarshal(wrapped.Item, rewritten.Interface()); err != nil {
+		return err
+	}
+	item.Set(rewritten.Elem())
+	return nil
+}
+
+// contentItem returns the settable item at field[index] together with the
+// schema of its element type.
+func contentItem(ideas *types.ContentIdeas, field string, index int) (reflect.Value, map[string]any, bool) {
+	v := reflect.ValueOf(ideas).Elem()
+	for i := 0; i < v.NumField(); i++ {
+		if strings.Split(v.Type().Field(i).Tag.Get("json"), ",")[0] != field {
+			continue
+		}
+		items := v.Field(i)
+		if index < 0 || index >= items.Len() {
+			return reflect.Value{}, nil, false
+		}
+		return items.Index(index), schemaForType(items.Type().Elem()), true
+	}
+	return reflect.Value{}, nil, false
+}
+
+func itemFlags(flags []types.ContentFlag, field string, index int) []types.ContentFlag {
+	var out []types.ContentFlag
+	for _, f := range flags {
+		if f.Field == field && f.Index == index {
+			out = append(out, f)
+		}
+	}
+	return out
+}
@@ -0,0 +1,220 @@
+package pkg
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/iamlucif3r/sarjan/internal/types"
+)
+
+func TestTweetLength(t *testing.T) {
+	cases := map[string]int{
+		"hello":             5,
+		"héllo wörld":       11,
+		"quote “this” — ok": 17,
+		"日本":                4,
+		"🔥":                 2,
+		"❤️":                2, // heart plus variation selector
+		"👍🏽":                2, // skin tone modifier
+		"👩‍💻":               2, // zero-width joiner sequence
+		"👨‍👩‍👧‍👦":           2,
+		"🇺🇸":                2,
+		"🇺🇸🇬🇧":              4,
+		"🏴󠁧󠁢󠁥󠁮󠁧󠁿":           2, // tag sequence
+		"see https://example.com/a/very/long/path/that/x/wraps now": 4 + tcoLength + 4,
+		"Patch now 👩‍💻🔥":                                            14,
+	}
+	for text, want := range cases {
+		if got := TweetLength(text); got != want {
+			t.Errorf("TweetLength(%q) = %d, want %d", text, got, want)
+		}
+	}
+}
+
+// contentFrom decodes raw, for fields whose element types are unnamed.
+func contentFrom(raw string) types.ContentIdeas {
+	var ideas types.ContentIdeas
+	if err := json.Unmarshal([]byte(raw), &ideas); err != nil {
+		panic(err)
+	}
+	return ideas
+}
+
+// flagKeys lists flags as "path rule" for comparison.
+func flagKeys(flags []types.ContentFlag) []string {
+	keys := make([]string, len(flags))
+	for i, f := range flags {
+		keys[i] = f.Path + " " + f.Rule
+	}
+	return keys
+}
+
+func TestValidatePlatformRules(t *testing.T) {
+	long := strings.Repeat("word ", 130)
+	cases := []struct {
+		name   string
+		ideas  types.ContentIdeas
+		banned []string
+		want   []string
+	}{
+		{"within limits", types.ContentIdeas{
+			TwitterPosts:   []string{strings.Repeat("a", 280), "#one #two #three"},
+			LinkedInPosts:  []string{long + "\n\n" + long},
+			InstagramPosts: []string{"Hook line\nsecond\nthird"},
+		}, nil, nil},
+		{"tweet length", types.ContentIdeas{TwitterPosts: []string{strings.Repeat("a", 281), strings.Repeat("日", 141)}},
+			nil, []string{"twitter_posts[0] length", "twitter_posts[1] length"}},
+		{"youtube title length", contentFrom(fmt.Sprintf(`{"youtube_video_ideas": [{"title": %q, "hook": %q}]}`, strings.Repeat("t", 101), strings.Repeat("h", 500))),
+			nil, []string{"youtube_video_ideas[0].title length"}},
+		{"hashtags", types.ContentIdeas{TwitterPosts: []string{"#one #two #three #four", "C# and issue#4 are not tags"}},
+			nil, []string{"twitter_posts[0] hashtags"}},
+		{"lines in the instagram preview", types.ContentIdeas{InstagramPosts: []string{
+			"one\ntwo\nthree\nfour",
+			strings.Repeat("x", 125) + "\ntwo\nthree\nfour\nfive",
+			"one\n\n\ntwo\n  \nthree",
+		}}, nil, []string{"instagram_posts[0] lines"}},
+		{"line breaks", types.ContentIdeas{LinkedInPosts: []string{long + long, strings.Repeat("x", 600), long + "\n" + long}},
+			nil, []string{"linkedin_posts[0] line_breaks"}},
+		{"banned phrase", contentFrom(`{
+			"twitter_posts": ["A real GAME CHANGER for defenders", "AIDS research is not AI hype", "Pair programming with an LLM", "game changers ahead"],
+			"instagram_reels": [{"idea": "Why AI-generated phishing works", "caption_style": "bold"}],
+			"linkedin_posts": ["Use (c)opyright notices"]
+		}`), []string{"game changer", " ai ", "", "(c)"}, []string{
+			"twitter_posts[0] banned_phrase",
+			"twitter_posts[1] banned_phrase",
+			"linkedin_posts[0] banned_phrase",
+			"instagram_reels[0].idea banned_phrase",
+		}},
+		{"thread length", types.ContentIdeas{TwitterThreads: []types.TwitterThread{
+			{Title: "Too long", Tweets: []string{"1", "2", "3", "4", "5", "6", "7"}},
+			{Title: "Fine", Tweets: []string{"1", "2", "3", "4", "5", "6"}},
+			{Title: "Long tweet", Tweets: []string{"1", strings.Repeat("b", 300)}},
+		}}, nil, []string{"twitter_threads[0].tweets thread_length", "twitter_threads[2].tweets[1] length"}},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got := flagKeys(ValidatePlatformRules(c.ideas, types.BrandProfile{BannedWords: c.banned}))
+			if !slices.Equal(got, c.want) {
+				t.Errorf("flags = %q, want %q", got, c.want)
+			}
+		})
+	}
+}
+
+// scriptedLLM answers the nth JSON request with outputs[n], repeating the
+// last output once they run out.
+type scriptedLLM struct {
+	outputs []string
+
+	mu       sync.Mutex
+	requests []string
+}
+
+func (s *scriptedLLM) Model() string { return "scripted" }
+
+func (s *scriptedLLM) Generate(ctx context.Context, prompt string, opts GenerateOptions) (string, error) {
+	return s.GenerateJSON(ctx, prompt, nil, opts)
+}
+
+func (s *scriptedLLM) GenerateJSON(ctx context.Context, prompt string, schema any, opts GenerateOptions) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	output := s.outputs[min(len(s.requests), len(s.outputs)-1)]
+	s.requests = append(s.requests, prompt)
+	return output, nil
+}
+
+func (s *scriptedLLM) Stream(ctx context.Context, prompt string, opts GenerateOptions, onChunk func(string) error) error {
+	output, err := s.Generate(ctx, prompt, opts)
+	if err != nil {
+		return err
+	}
+	return onChunk(output)
+}
+
+func TestFixPlatformViolations(t *testing.T) {
+	prompts, err := NewPromptRegistry("", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rewrite, err := prompts.Get(PromptRewriteItem)
+	if err != nil {
+		t.Fatal(err)
+	}
+	brand := DefaultBrandProfile()
+	brand.BannedWords = []string{"game changer"}
+	tooLong := strings.Repeat("a", 300)
+
+	cases := []struct {
+		name      string
+		outputs   []string
+		attempts  int
+		wantTweet string
+		wantFlags []string
+		wantCalls int
+	}{
+		{"rewrite fixes the item", []string{`{"item": "Patch your VPN today."}`}, 3,
+			"Patch your VPN today.", nil, 1},
+		{"second attempt fixes the item", []string{`{"item": "A game changer: patch now."}`, "```json\n{\"item\": \"Patch now.\"}\n```"}, 3,
+			"Patch now.", nil, 2},
+		{"rewrite breaking the contract is discarded", []string{`{"item": "  "}`}, 3,
+			tooLong + " game changer", []string{"twitter_posts[1] length", "twitter_posts[1] banned_phrase"}, 1},
+		{"rewrite of the wrong shape is discarded", []string{`{"item": ["Patch now."]}`}, 3,
+			tooLong + " game changer", []string{"twitter_posts[1] length", "twitter_posts[1] banned_phrase"}, 1},
+		{"attempts run out", []string{`{"item": "` + tooLong + `"}`}, 2,
+			tooLong, []string{"twitter_posts[1] length"}, 2},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			ideas := types.ContentIdeas{TwitterPosts: []string{"Fine as it is.", tooLong + " game changer"}}
+			flags := ValidatePlatformRules(ideas, brand)
+			client := &scriptedLLM{outputs: c.outputs}
+
+			remaining := FixPlatformViolations(context.Background(), client, rewrite, &ideas, brand, flags, c.attempts)
+			if got := flagKeys(remaining); !slices.Equal(got, c.wantFlags) {
+				t.Errorf("remaining flags = %q, want %q", got, c.wantFlags)
+			}
+			if ideas.TwitterPosts[1] != c.wantTweet {
+				t.Errorf("tweet = %q, want %q", ideas.TwitterPosts[1], c.wantTweet)
+			}
+			if ideas.TwitterPosts[0] != "Fine as it is." {
+				t.Errorf("unflagged tweet changed to %q", ideas.TwitterPosts[0])
+			}
+			if len(client.requests) != c.wantCalls {
+				t.Fatalf("model was asked %d times, want %d", len(client.requests), c.wantCalls)
+			}
+			if first := client.requests[0]; !strings.Contains(first, `uses the banned phrase "game changer"`) || !strings.Contains(first, tooLong) {
+				t.Errorf("rewrite prompt does not carry the item and its problems:\n%s", first)
+			}
+		})
+	}
+}
+
+func TestContentItem(t *testing.T) {
+	ideas := contentFrom(`{"twitter_posts": ["a", "b"], "instagram_reels": [{"idea": "idea", "caption_style": "bold"}]}`)
+	item, schema, ok := contentItem(&ideas, "instagram_reels", 0)
+	if !ok || schema["type"] != "object" || item.FieldByName("Idea").String() != "idea" {
+		t.Fatalf("contentItem(instagram_reels, 0) = %v, %v, %v", item, schema, ok)
+	}
+	item, schema, ok = contentItem(&ideas, "twitter_posts", 1)
+	if !ok || schema["type"] != "string" {
+		t.Fatalf("contentItem(twitter_posts, 1) = %v, %v, %v", item, schema, ok)
+	}
+	item.SetString("changed")
+	if ideas.TwitterPosts[1] != "changed" {
+		t.Errorf("setting the item did not change the content")
+	}
+	for _, c := range []struct {
+		field string
+		index int
+	}{{"twitter_posts", 2}, {"twitter_posts", -1}, {"linkedin_posts", 0}, {"nope", 0}} {
+		if _, _, ok := contentItem(&ideas, c.field, c.index); ok {
+			t.Errorf("contentItem(%s, %d) found an item", c.field, c.index)
+		}
+	}
+}
@@ -0,0 +1,262 @@
+package pkg
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/iamlucif3r/sarjan/internal/types"
+)
+
+// modelWindows lists the native context length of common model families,
+// matched by the longest prefix of the model name.
+var modelWindows = map[string]int{
+	"llama2":      4096,
+	"llama3":      8192,
+	"llama3.1":    131072,
+	"llama3.2":    131072,
+	"llama3.3":    131072,
+	"mistral":     32768,
+	"mixtral":     32768,
+	"qwen2":       32768,
+	"qwen2.5":     32768,
+	"qwen3":       40960,
+	"gemma":       8192,
+	"gemma2":      8192,
+	"gemma3":      131072,
+	"phi3":        4096,
+	"phi4":        16384,
+	"deepseek-r1": 131072,
+	"command-r":   131072,
+}
+
+// modelCharsPerToken approximates tokenizer density. Families with 32k
+// vocabularies split text into more tokens than the 128k+ vocabularies.
+var modelCharsPerToken = map[string]float64{
+	"llama2":  3.3,
+	"mistral": 3.3,
+	"mixtral": 3.3,
+}
+
+const (
+	defaultContextWindow = 8192
+	// autoContextCap keeps automatically chosen windows within what a local
+	// Ollama instance can usually hold in memory.
+	autoContextCap       = 16384
+	defaultCharsPerToken = 3.8
+	defaultOutputReserve = 2048
+)
+
+// TokenBudget describes how much text a model can accept in one call.
+type TokenBudget struct {
+	ContextWindow int
+	// ReserveOutput is held back for the model's reply.
+	ReserveOutput int
+	CharsPerToken float64
+}
+
+func longestPrefix[T any](table map[string]T, model string) (T, bool) {
+	name := strings.ToLower(model)
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		name = name[i+1:]
+	}
+	var best T
+	bestLen := 0
+	for prefix, v := range table {
+		if strings.HasPrefix(name, prefix) && len(prefix) > bestLen {
+			best, bestLen = v, len(prefix)
+		}
+	}
+	return best, bestLen > 0
+}
+
+// BudgetForModel picks a budget for model. A positive contextWindow
+// overrides the table; otherwise the native window is capped at
+// autoContextCap.
+func BudgetForModel(model string, contextWindow, reserveOutput int) TokenBudget {
+	budget := TokenBudget{
+		ContextWindow: contextWindow,
+		ReserveOutput: reserveOutput,
+		CharsPerToken: defaultCharsPerToken,
+	}
+	if budget.ContextWindow <= 0 {
+		window, ok := longestPrefix(modelWindows, model)
+		if !ok {
+			window = defaultContextWindow
+		}
+		budget.ContextWindow = min(window, autoContextCap)
+	}
+	if budget.ReserveOutput <= 0 {
+		budget.ReserveOutput = defaultOutputReserve
+	}
+	if cpt, ok := longestPrefix(modelCharsPerToken, model); ok {
+		budget.CharsPerToken = cpt
+	}
+	return budget
+}
+
+// Estimate approximates the token count of s.
+func (b TokenBudget) Estimate(s string) int {
+	return int(float64(utf8.RuneCountInString(s))/b.CharsPerToken) + 1
+}
+
+// Truncate cuts s to about maxTokens, preferring a sentence or paragraph
+// boundary in the last fifth of the allowance.
+func (b TokenBudget) Truncate(s string, maxTokens int) string {
+	if b.Estimate(s) <= maxTokens {
+		return s
+	}
+	maxRunes := int(float64(maxTokens) * b.CharsPerToken)
+	if maxRunes <= 0 {
+		return ""
+	}
+	runes := []rune(s)
+	if len(runes) <= maxRunes {
+		return s
+	}
+	cut := string(runes[:maxRunes])
+	if i := strings.LastIndexAny(cut, ".\n"); i > len(cut)*4/5 {
+		cut = cut[:i+1]
+	}
+	return strings.TrimSpace(cut) + " …[truncated]"
+}
+
+// PromptBuilder fits article bodies into the space a prompt template
+// leaves free, summarising or truncating the ones that do not fit.
+type PromptBuilder struct {
+	Budget TokenBudget
+	Client LLMClient
+	// Summarise enables map-reduce summarisation of oversized articles;
+	// without it they are truncated.
+	Summarise bool
+}
+
+// FitArticles returns copies of articles whose bodies together fit in the
+// budget left after templateTokens (the prompt rendered without bodies)
+// and the output reserve. Short articles
+// keep their full text; the remaining space is shared evenly among the
+// long ones.
+func (p PromptBuilder) FitArticles(ctx context.Context, templateTokens int, articles []types.JudgedArticle) []types.JudgedArticle {
+	fitted := make([]types.JudgedArticle, len(articles))
+	copy(fitted, articles)
+	if len(fitted) == 0 {
+		return fitted
+	}
+
+	available := p.Budget.ContextWindow - p.Budget.ReserveOutput - templateTokens
+	if available < len(fitted) {
+		available = len(fitted)
+	}
+
+	order := make([]int, len(fitted))
+	sizes := make([]int, len(fitted))
+	for i, a := range fitted {
+		order[i] = i
+		sizes[i] = p.Budget.Estimate(a.Content)
+	}
+	sort.SliceStable(order, func(a, b int) bool { return sizes[order[a]] < sizes[order[b]] })
+
+	remaining := available
+	for k, i := range order {
+		share := remaining / (len(order) - k)
+		alloc := min(sizes[i], share)
+		remaining -= alloc
+		if sizes[i] <= alloc {
+			continue
+		}
+
+		content := fitted[i].Content
+		if p.Summarise && p.Client != nil && sizes[i] > alloc*3/2 {
+			summary, err := p.summarise(ctx, content, alloc)
+			if err != nil {
+				log.Printf("[WARN] Summarising %q failed, truncating instead: %v", fitted[i].Title, err)
+			} else {
+				content = summary
+			}
+		}
+		fitted[i].Content = p.Budget.Truncate(content, alloc)
+		log.Printf("[INFO] Article %q reduced from ~%d to ~%d tokens", fitted[i].Title, sizes[i], p.Budget.Estimate(fitted[i].Content))
+	}
+	return fitted
+}
+
+const summaryInstructions = `Summarise the following %s of a cybersecurity news article in at most %d words.
+Keep every concrete technical detail: CVE IDs, affected products and versions, exploitation steps, tooling, indicators of compromise, detection ideas and mitigations.
+Return only the summary text.
+
+%s`
+
+// summarise condenses text to roughly target tokens: each chunk that fits
+// in the context window is summarised on its own (map), then the partial
+// summaries are condensed together if they are still too long (reduce).
+func (p PromptBuilder) summarise(ctx context.Context, text string, target int) (string, error) {
+	overhead := p.Budget.Estimate(summaryInstructions) + 16
+	chunkTokens := (p.Budget.ContextWindow - p.Budget.ReserveOutput - overhead) * 3 / 4
+	if chunkTokens < 256 {
+		return "", fmt.Errorf("context window too small to summarise")
+	}
+	opts := GenerateOptions{Temperature: Temperature(0.2), NumCtx: p.Budget.ContextWindow}
+	words := func(tokens int) int { return max(tokens*3/4, 30) }
+
+	chunks := p.chunk(text, chunkTokens)
+	perChunk := max(target/len(chunks), 64)
+	var partials []string
+	for i, chunk := range chunks {
+		part := "part"
+		if len(chunks) == 1 {
+			part = "text"
+		}
+		summary, err := p.Client.Generate(ctx, fmt.Sprintf(summaryInstructions, part, words(perChunk), chunk), opts)
+		if err != nil {
+			return "", fmt.Errorf("summarising chunk %d/%d: %w", i+1, len(chunks), err)
+		}
+		partials = append(partials, strings.TrimSpace(summary))
+	}
+
+	combined := strings.Join(partials, "\n\n")
+	for round := 0; p.Budget.Estimate(combined) > target && round < 3; round++ {
+		reduced, err := p.Client.Generate(ctx, fmt.Sprintf(summaryInstructions, "set of partial summaries", words(target), p.Budget.Truncate(combined, chunkTokens)), opts)
+		if err != nil {
+			return combined, fmt.Errorf("reducing summaries: %w", err)
+		}
+		combined = strings.TrimSpace(reduced)
+	}
+	return combined, nil
+}
+
+// chunk splits text on paragraph boundaries into pieces of at most
+// maxTokens, hard-cutting any single paragraph that is larger.
+func (p PromptBuilder) chunk(text string, maxTokens int) []string {
+	var chunks []string
+	var current strings.Builder
+	flush := func() {
+		if current.Len() > 0 {
+			chunks = append(chunks, current.String())
+			current.Reset()
+		}
+	}
+	for _, para := range strings.Split(text, "\n\n") {
+		for p.Budget.Estimate(para) > maxTokens {
+			flush()
+			runes := []rune(para)
+			n := int(float64(maxTokens) * p.Budget.CharsPerToken)
+			chunks = append(chunks, string(runes[:n]))
+			para = string(runes[n:])
+		}
+		if p.Budget.Estimate(current.String()+para) > maxTokens {
+			flush()
+		}
+		if current.Len() > 0 {
+			current.WriteString("\n\n")
+		}
+		current.WriteString(para)
+	}
+	flush()
+	if len(chunks) == 0 {
+		chunks = []string{text}
+	}
+	return chunks
+}
@@ -0,0 +1,319 @@
+package pkg
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"embed"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/iamlucif3r/sarjan/internal/types"
+)
+
+// Prompt names used by the pipeline.
+const (
+	PromptContentIdeas  = "content_ideas"
+	PromptJudgeArticles = "judge_articles"
+	PromptRewriteItem   = "rewrite_item"
+)
+
+// builtinPrompts ship inside the binary so SARJAN runs without a prompts
+// directory. Files on disk with the same name and version override them.
+//
+//go:embed prompts
+var builtinPrompts embed.FS
+
+// PromptData is the value templates are executed against.
+type PromptData struct {
+	Brand        types.BrandProfile
+	Articles     []types.JudgedArticle
+	ArticlesJSON string
+	Counts       map[string]ItemCount
+	// Format is the JSON skeleton for the fields in Counts.
+	Format string
+	// Platform, Item and Problems describe a single item being rewritten.
+	Platform string
+	Item     string
+	Problems []string
+}
+
+// Wants reports whether a ContentIdeas field is being generated.
+func (d PromptData) Wants(field string) bool {
+	_, ok := d.Counts[field]
+	return ok
+}
+
+// WantsPlatform reports whether any of a platform's fields are being
+// generated.
+func (d PromptData) WantsPlatform(platform string) bool {
+	for _, field := range types.PlatformFields[platform] {
+		if d.Wants(field) {
+			return true
+		}
+	}
+	return false
+}
+
+// Count renders the requested number of items for a ContentIdeas field,
+// e.g. "5" or "1–2".
+func (d PromptData) Count(field string) string {
+	c, ok := d.Counts[field]
+	if !ok {
+		return "0"
+	}
+	if c.Min == c.Max {
+		return strconv.Itoa(c.Min)
+	}
+	return fmt.Sprintf("%d–%d", c.Min, c.Max)
+}
+
+// PromptTemplate is one named, versioned prompt.
+type PromptTemplate struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+	Hash    string `json:"hash"`
+	Origin  string `json:"origin"`
+	Source  string `json:"-"`
+	tmpl    *template.Template
+}
+
+// ID identifies the exact text of the template, so edits that forget to
+// bump the version are still distinguishable in stored bundles.
+func (t *PromptTemplate) ID() string {
+	return fmt.Sprintf("%s/%s@%s", t.Name, t.Version, t.Hash[:12])
+}
+
+func (t *PromptTemplate) Render(data PromptData) (string, error) {
+	var b bytes.Buffer
+	if err := t.tmpl.Execute(&b, data); err != nil {
+		return "", fmt.Errorf("rendering prompt %s: %w", t.ID(), err)
+	}
+	return b.String(), nil
+}
+
+var promptFuncs = template.FuncMap{
+	"join": strings.Join,
+}
+
+func parsePromptTemplate(name, version, origin string, source []byte) (*PromptTemplate, error) {
+	tmpl, err := template.New(name + "/" + version).Option("missingkey=error").Funcs(promptFuncs).Parse(string(source))
+	if err != nil {
+		return nil, fmt.Errorf("parsing prompt %s/%s: %w", name, version, err)
+	}
+	sum := sha256.Sum256(source)
+	return &PromptTemplate{
+		Name:    name,
+		Version: version,
+		Hash:    hex.EncodeToString(sum[:]),
+		Origin:  origin,
+		Source:  string(source),
+		tmpl:    tmpl,
+	}, nil
+}
+
+// PromptRegistry holds every known version of every prompt, laid out as
+// <name>/<version>.tmpl, and resolves which version is active. The
+// highest version wins unless a pin selects another.
+type PromptRegistry struct {
+	dir  string
+	pins map[string]string
+
+	mu        sync.RWMutex
+	templates map[string]map[string]*PromptTemplate
+	stamp     string
+}
+
+func NewPromptRegistry(dir string, pins map[string]string) (*PromptRegistry, error) {
+	r := &PromptRegistry{dir: dir, pins: pins}
+	if err := r.Reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Reload re-reads the built-in prompts and the prompts directory. On any
+// parse error the previous set stays active.
+func (r *PromptRegistry) Reload() error {
+	templates := map[string]map[string]*PromptTemplate{}
+	add := func(fsys fs.FS, origin string) error {
+		return fs.WalkDir(fsys, ".", func(p string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() || path.Ext(p) != ".tmpl" {
+				return err
+			}
+			name := path.Base(path.Dir(p))
+			version := strings.TrimSuffix(path.Base(p), ".tmpl")
+			source, err := fs.ReadFile(fsys, p)
+			if err != nil {
+				return err
+			}
+			t, err := parsePromptTemplate(name, version, origin, source)
+			if err != nil {
+				return err
+			}
+			if templates[name] == nil {
+				templates[name] = map[string]*PromptTemplate{}
+			}
+			templates[name][version] = t
+			return nil
+		})
+	}
+
+	builtin, _ := fs.Sub(builtinPrompts, "prompts")
+	if err := add(builtin, "builtin"); err != nil {
+		return err
+	}
+	stamp := ""
+	if r.dir != "" {
+		if info, err := os.Stat(r.dir); err == nil && info.IsDir() {
+			if err := add(os.DirFS(r.dir), r.dir); err != nil {
+				return err
+			}
+			stamp = r.dirStamp()
+		}
+	}
+
+	for name, version := range r.pins {
+		if templates[name][version] == nil {
+			return fmt.Errorf("pinned prompt %s/%s not found", name, version)
+		}
+	}
+
+	r.mu.Lock()
+	r.templates = templates
+	r.stamp = stamp
+	r.mu.Unlock()
+	return nil
+}
+
+// dirStamp summarises file names, sizes and modification times so Watch
+// can tell when the directory changed.
+func (r *PromptRegistry) dirStamp() string {
+	var b strings.Builder
+	_ = fs.WalkDir(os.DirFS(r.dir), ".", func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return nil
+		}
+		if info, err := d.Info(); err == nil {
+			fmt.Fprintf(&b, "%s:%d:%d;", p, info.Size(), info.ModTime().UnixNano())
+		}
+		return nil
+	})
+	return b.String()
+}
+
+// Watch polls the prompts directory and reloads when it changes.
+func (r *PromptRegistry) Watch(ctx context.Context, interval time.Duration) {
+	if r.dir == "" || interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			r.mu.RLock()
+			previous := r.stamp
+			r.mu.RUnlock()
+			if r.dirStamp() == previous {
+				continue
+			}
+			if err := r.Reload(); err != nil {
+				log.Printf("[ERROR] Failed to reload prompts: %v", err)
+				continue
+			}
+			log.Println("[INFO] Reloaded prompt templates from", r.dir)
+		}
+	}
+}
+
+// Get returns the active version of a prompt.
+func (r *PromptRegistry) Get(name string) (*PromptTemplate, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	versions := r.templates[name]
+	if len(versions) == 0 {
+		return nil, fmt.Errorf("unknown prompt %q", name)
+	}
+	if pinned, ok := r.pins[name]; ok {
+		return versions[pinned], nil
+	}
+	return versions[latestVersion(versions)], nil
+}
+
+type PromptInfo struct {
+	Name     string           `json:"name"`
+	Active   string           `json:"active"`
+	Versions []PromptTemplate `json:"versions"`
+}
+
+func (r *PromptRegistry) List() []PromptInfo {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var infos []PromptInfo
+	for name, versions := range r.templates {
+		info := PromptInfo{Name: name, Active: latestVersion(versions)}
+		if pinned, ok := r.pins[name]; ok {
+			info.Active = pinned
+		}
+		for _, t := range versions {
+			info.Versions = append(info.Versions, *t)
+		}
+		sort.Slice(info.Versions, func(i, j int) bool {
+			return compareVersions(info.Versions[i].Version, info.Versions[j].Version) < 0
+		})
+		infos = append(infos, info)
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Name < infos[j].Name })
+	return infos
+}
+
+func latestVersion(versions map[string]*PromptTemplate) string {
+	latest := ""
+	for v := range versions {
+		if latest == "" || compareVersions(v, latest) > 0 {
+			latest = v
+		}
+	}
+	return latest
+}
+
+// compareVersions orders "v2" before "v10" and "v1.2" before "v1.10",
+// falling back to string comparison for non-numeric parts.
+func compareVersions(a, b string) int {
+	pa := strings.Split(strings.TrimPrefix(a, "v"), ".")
+	pb := strings.Split(strings.TrimPrefix(b, "v"), ".")
+	for i := 0; i < len(pa) || i < len(pb); i++ {
+		var sa, sb string
+		if i < len(pa) {
+			sa = pa[i]
+		}
+		if i < len(pb) {
+			sb = pb[i]
+		}
+		na, errA := strconv.Atoi(sa)
+		nb, errB := strconv.Atoi(sb)
+		switch {
+		case errA == nil && errB == nil && na != nb:
+			if na < nb {
+				return -1
+			}
+			return 1
+		case (errA != nil || errB != nil) && sa != sb:
+			return strings.Compare(sa, sb)
+		}
+	}
+	return 0
+}
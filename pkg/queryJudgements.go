@@ -0,0 +1,44 @@
+package pkg
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/kaptinlin/jsonrepair"
+)
+
+// Judgement is one article's comparative score. It also accepts a bare
+// number so older-style {"Article 1": 7} replies still parse.
+type Judgement struct {
+	Score     float64 `json:"score"`
+	Rationale string  `json:"rationale"`
+}
+
+func (j *Judgement) UnmarshalJSON(data []byte) error {
+	var score float64
+	if err := json.Unmarshal(data, &score); err == nil {
+		j.Score = score
+		return nil
+	}
+	type plain Judgement
+	return json.Unmarshal(data, (*plain)(j))
+}
+
+func QueryJudgements(ctx context.Context, client LLMClient, prompt string) (map[string]Judgement, error) {
+	completion, err := client.GenerateJSON(ctx, prompt, nil, GenerateOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to call LLM: %v", err)
+	}
+
+	repaired, err := jsonrepair.JSONRepair(completion)
+	if err != nil {
+		return nil, fmt.Errorf("failed to repair JSON: %v", err)
+	}
+	var result map[string]Judgement
+	if err := json.Unmarshal([]byte(repaired), &result); err != nil {
+		return nil, fmt.Errorf("failed to parse extracted JSON: %v", err)
+	}
+
+	return result, nil
+}
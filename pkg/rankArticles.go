@@ -0,0 +1,99 @@
+package pkg
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+
+	"github.com/iamlucif3r/sarjan/internal/database"
+	"github.com/iamlucif3r/sarjan/internal/types"
+)
+
+type RankOptions struct {
+	// BatchSize is the most articles judged in one prompt.
+	BatchSize int
+	// Prompt is the judge_articles template. Nil uses the built-in version.
+	Prompt *PromptTemplate
+	// Brand is whose audience articles are judged for.
+	Brand types.BrandProfile
+}
+
+// RankArticles orders candidates by SARJAN's own comparative judging. When
+// there are more candidates than fit in one batch, it runs a tournament:
+// each batch is judged, the top half advances, and rounds repeat until the
+// remaining pool fits in a single final batch.
+func RankArticles(ctx context.Context, client LLMClient, candidates []types.JudgedArticle, opts RankOptions) ([]types.JudgedArticle, error) {
+	if opts.BatchSize < 2 {
+		opts.BatchSize = 2
+	}
+
+	var eliminated [][]types.JudgedArticle
+	pool := candidates
+	round := 1
+	for len(pool) > opts.BatchSize {
+		var next, out []types.JudgedArticle
+		for start := 0; start < len(pool); start += opts.BatchSize {
+			end := min(start+opts.BatchSize, len(pool))
+			judged, err := judgeBatch(ctx, client, opts, pool[start:end], round)
+			if err != nil {
+				return nil, err
+			}
+			advance := (len(judged) + 1) / 2
+			next = append(next, judged[:advance]...)
+			out = append(out, judged[advance:]...)
+		}
+		log.Printf("[INFO] Ranking round %d: %d of %d articles advance", round, len(next), len(pool))
+		eliminated = append(eliminated, out)
+		pool = next
+		round++
+	}
+
+	final, err := judgeBatch(ctx, client, opts, pool, round)
+	if err != nil {
+		return nil, err
+	}
+
+	ranked := final
+	for i := len(eliminated) - 1; i >= 0; i-- {
+		out := eliminated[i]
+		sort.SliceStable(out, func(a, b int) bool { return out[a].Score > out[b].Score })
+		ranked = append(ranked, out...)
+	}
+	return ranked, nil
+}
+
+func judgeBatch(ctx context.Context, client LLMClient, opts RankOptions, batch []types.JudgedArticle, round int) ([]types.JudgedArticle, error) {
+	articles := make([]types.Article, len(batch))
+	for i, a := range batch {
+		articles[i] = a.Article
+	}
+	judged, err := JudgeArticlesComparatively(ctx, client, opts.Prompt, opts.Brand, articles)
+	if err != nil {
+		return nil, fmt.Errorf("ranking round %d: %w", round, err)
+	}
+	for i := range judged {
+		judged[i].Round = round
+	}
+	return judged, nil
+}
+
+// RankTopCandidates pulls the candidates selected by q, ranks them and
+// persists the result. jobID is nil for dry runs.
+func RankTopCandidates(ctx context.Context, db database.Repository, client LLMClient, q types.ArticleQuery, opts RankOptions, jobID *int64) ([]types.JudgedArticle, error) {
+	candidates, err := db.FetchTopRankedArticles(q)
+	if err != nil {
+		return nil, err
+	}
+	if len(candidates) == 0 {
+		return nil, fmt.Errorf("no candidate articles available")
+	}
+	ranked, err := RankArticles(ctx, client, candidates, opts)
+	if err != nil {
+		return candidates, err
+	}
+	if err := db.SaveArticleRankings(jobID, ranked); err != nil {
+		log.Println("[ERROR]", err)
+	}
+	return ranked, nil
+}
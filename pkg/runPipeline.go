@@ -0,0 +1,250 @@
+package pkg
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"path/filepath"
+	"time"
+
+	"github.com/iamlucif3r/sarjan/internal/types"
+	"github.com/iamlucif3r/sarjan/internal/utils"
+)
+
+const (
+	OutputDir    = "output"
+	ArtifactPath = "/artifacts"
+)
+
+// RunGenerateJob drives a queued job through fetch → generate → render →
+// deliver, recording each transition and any stage error on the job row.
+// The job fails once Config.JobTimeout has passed.
+func (a *App) RunGenerateJob(ctx context.Context, job types.Job) error {
+	db, Config, client := a.Store, a.Config, a.LLM
+	if Config.JobTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, Config.JobTimeout)
+		defer cancel()
+	}
+	jobID := job.ID
+	query := job.Request.Articles
+	fail := func(stage types.JobState, err error) error {
+		log.Printf("[ERROR] Job %d failed while %s: %v", jobID, stage, err)
+		if dbErr := db.SetJobStageError(jobID, stage, err.Error()); dbErr != nil {
+			log.Println("[ERROR]", dbErr)
+		}
+		if dbErr := db.SetJobState(jobID, types.JobFailed); dbErr != nil {
+			log.Println("[ERROR]", dbErr)
+		}
+		return err
+	}
+	enter := func(stage types.JobState) error {
+		log.Printf("[INFO] Job %d: %s", jobID, stage)
+		return db.SetJobState(jobID, stage)
+	}
+
+	if err := enter(types.JobFetching); err != nil {
+		return fail(types.JobFetching, err)
+	}
+	brand, err := a.Brands.Get(job.Request.Brand)
+	if err != nil {
+		return fail(types.JobFetching, err)
+	}
+	var articles []types.JudgedArticle
+	if Config.RerankEnabled {
+		if err := enter(types.JobRanking); err != nil {
+			return fail(types.JobRanking, err)
+		}
+		winners := query.Limit
+		if winners < 1 {
+			winners = Config.RerankWinners
+		}
+		query.Limit = max(Config.RerankCandidates, winners)
+		judgePrompt, err := a.Prompts.Get(PromptJudgeArticles)
+		if err != nil {
+			return fail(types.JobRanking, err)
+		}
+		ranked, err := RankTopCandidates(ctx, db, client, query, RankOptions{BatchSize: Config.RerankBatchSize, Prompt: judgePrompt, Brand: brand}, &jobID)
+		if err != nil && len(ranked) == 0 {
+			return fail(types.JobRanking, err)
+		}
+		if err != nil {
+			// Ranking is an optimisation: fall back to the upstream order.
+			log.Printf("[WARN] Job %d ranking failed, using upstream order: %v", jobID, err)
+			if dbErr := db.SetJobStageError(jobID, types.JobRanking, err.Error()); dbErr != nil {
+				log.Println("[ERROR]", dbErr)
+			}
+		}
+		articles = ranked[:min(winners, len(ranked))]
+	} else {
+		if query.Limit < 1 {
+			query.Limit = 1
+		}
+		articles, err = db.FetchTopRankedArticles(query)
+		if err != nil {
+			return fail(types.JobFetching, fmt.Errorf("failed to fetch articles: %w", err))
+		}
+	}
+	if len(articles) == 0 {
+		return fail(types.JobFetching, fmt.Errorf("no articles available"))
+	}
+	log.Println("[INFO] Selected ", len(articles), " articles for generation")
+
+	if Config.EnrichEnabled {
+		if err := enter(types.JobEnriching); err != nil {
+			return fail(types.JobEnriching, err)
+		}
+		var results []EnrichResult
+		articles, results = EnrichArticleContent(ctx, db, a.Fetcher, articles, EnrichOptions{
+			Concurrency: Config.EnrichConcurrency,
+			HostDelay:   Config.EnrichHostDelay,
+		})
+		if summary := SummariseEnrichFailures(results); summary != "" {
+			log.Printf("[WARN] Job %d: %s", jobID, summary)
+			if err := db.SetJobStageError(jobID, types.JobEnriching, summary); err != nil {
+				log.Println("[ERROR]", err)
+			}
+		}
+		if ctx.Err() != nil {
+			return fail(types.JobEnriching, ctx.Err())
+		}
+	}
+
+	if err := enter(types.JobGenerating); err != nil {
+		return fail(types.JobGenerating, err)
+	}
+	contentPrompt, err := a.Prompts.Get(PromptContentIdeas)
+	if err != nil {
+		return fail(types.JobGenerating, err)
+	}
+	if err := db.SavePromptVersion(contentPrompt.ID(), contentPrompt.Name, contentPrompt.Version, contentPrompt.Hash, contentPrompt.Source); err != nil {
+		log.Println("[ERROR]", err)
+	}
+	reqs, err := ResolveRequirements(job.Request.Platforms, job.Request.Counts)
+	if err != nil {
+		return fail(types.JobGenerating, err)
+	}
+	result, err := GenerateContentIdeas(ctx, client, articles, ContentOptions{
+		MaxAttempts:  Config.GenerationAttempts,
+		Budget:       BudgetForModel(client.Model(), Config.LLMContextWindow, Config.LLMOutputReserve),
+		Summarise:    Config.PromptSummarise,
+		Prompt:       contentPrompt,
+		Brand:        brand,
+		Requirements: reqs,
+		Concurrency:  Config.GenerationConcurrency,
+	})
+	if err != nil {
+		return fail(types.JobGenerating, fmt.Errorf("failed to generate content ideas: %w", err))
+	}
+	bundle := result.Ideas
+	flags := ValidatePlatformRules(bundle, brand)
+	if len(flags) > 0 {
+		rewritePrompt, err := a.Prompts.Get(PromptRewriteItem)
+		if err != nil {
+			return fail(types.JobGenerating, err)
+		}
+		flags = FixPlatformViolations(ctx, client, rewritePrompt, &bundle, brand, flags, Config.GenerationAttempts)
+		if len(flags) > 0 {
+			log.Printf("[WARN] Job %d: %d content items still break platform rules", jobID, len(flags))
+		}
+	}
+	platformErrors := map[string]string{}
+	for platform, err := range result.Errors {
+		platformErrors[platform] = err.Error()
+		if dbErr := db.SetJobStageError(jobID, types.JobState(string(types.JobGenerating)+"."+platform), err.Error()); dbErr != nil {
+			log.Println("[ERROR]", dbErr)
+		}
+	}
+	articleIDs := make([]int64, len(articles))
+	for i, article := range articles {
+		articleIDs[i] = int64(article.ID)
+	}
+	bundleID, err := db.SaveBundle(types.Bundle{
+		JobID:         &jobID,
+		Brand:         brand.ID,
+		Model:         client.Model(),
+		PromptVersion: contentPrompt.ID(),
+		ArticleIDs:    articleIDs,
+		Content:       &bundle,
+		Errors:        platformErrors,
+		Flags:         flags,
+	})
+	if err != nil {
+		return fail(types.JobGenerating, fmt.Errorf("failed to save bundle: %w", err))
+	}
+	if err := db.AddJobArtifact(jobID, fmt.Sprintf("/bundles/%d", bundleID)); err != nil {
+		log.Println("[ERROR]", err)
+	}
+
+	if err := enter(types.JobRendering); err != nil {
+		return fail(types.JobRendering, err)
+	}
+	formats := job.Request.Formats
+	if len(formats) == 0 {
+		formats = Config.RenderFormats
+	}
+	doc := utils.Document{
+		Title:       "Content Ideas",
+		Brand:       brand.Name,
+		GeneratedAt: time.Now(),
+		Content:     bundle,
+		Flags:       flags,
+		Errors:      platformErrors,
+	}
+	for _, article := range articles {
+		doc.Sources = append(doc.Sources, utils.Source{Title: article.Title, URL: article.URL})
+	}
+	var files []string
+	for _, format := range formats {
+		renderer, err := a.Renderers.Get(format)
+		if err != nil {
+			return fail(types.JobRendering, err)
+		}
+		name := fmt.Sprintf("content_ideas_%d%s", jobID, renderer.Extension())
+		path := filepath.Join(brand.OutputDir, name)
+		if err := utils.RenderToFile(renderer, doc, path); err != nil {
+			return fail(types.JobRendering, err)
+		}
+		files = append(files, path)
+		if err := db.AddJobArtifact(jobID, ArtifactPath+"/"+brand.ID+"/"+name); err != nil {
+			log.Println("[ERROR]", err)
+		}
+	}
+
+	if err := enter(types.JobDelivering); err != nil {
+		return fail(types.JobDelivering, err)
+	}
+	recorder := a.Webhooks.Recording(func(attempt types.DeliveryAttempt) {
+		if err := db.SaveDeliveryAttempt(&jobID, attempt); err != nil {
+			log.Println("[ERROR]", err)
+		}
+	})
+	notifiers, err := a.Notifiers(brand, recorder)
+	if err != nil {
+		return fail(types.JobDelivering, err)
+	}
+	if len(notifiers) == 0 {
+		return fail(types.JobDelivering, fmt.Errorf("brand %s has no delivery sinks configured", brand.ID))
+	}
+
+	var deliveryErrs []error
+	for _, result := range utils.NotifyAll(ctx, notifiers, utils.Delivery{Doc: doc, Files: files}) {
+		if result.OK {
+			log.Printf("[Info] Job %d: delivered to %s in %dms", jobID, result.Sink, result.DurationMS)
+			continue
+		}
+		deliveryErrs = append(deliveryErrs, fmt.Errorf("%s: %s", result.Sink, result.Error))
+		if dbErr := db.SetJobStageError(jobID, types.JobState(string(types.JobDelivering)+"."+result.Sink), result.Error); dbErr != nil {
+			log.Println("[ERROR]", dbErr)
+		}
+	}
+	if len(deliveryErrs) == len(notifiers) {
+		return fail(types.JobDelivering, errors.Join(deliveryErrs...))
+	}
+
+	if err := db.SetJobState(jobID, types.JobDone); err != nil {
+		log.Println("[ERROR]", err)
+	}
+	return nil
+}
@@ -0,0 +1,422 @@
+package pkg
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/iamlucif3r/sarjan/internal/types"
+)
+
+type FetcherOptions struct {
+	ConnectTimeout time.Duration
+	// Timeout bounds the whole request, including reading the body.
+	Timeout      time.Duration
+	MaxBodyBytes int64
+	MaxRedirects int
+	UserAgent    string
+	// ContentTypes lists accepted media types; empty accepts anything.
+	ContentTypes []string
+	// Allowlist holds hostnames or CIDR ranges that may be fetched even
+	// though they resolve to private, loopback or link-local addresses.
+	Allowlist     []string
+	RespectRobots bool
+}
+
+func DefaultFetcherOptions() FetcherOptions {
+	return FetcherOptions{
+		ConnectTimeout: 5 * time.Second,
+		Timeout:        20 * time.Second,
+		MaxBodyBytes:   5 << 20,
+		MaxRedirects:   5,
+		UserAgent:      "SARJAN/1.0 (+https://github.com/iamlucif3r/sarjan)",
+		ContentTypes:   []string{"text/html", "application/xhtml+xml", "text/plain"},
+		RespectRobots:  true,
+	}
+}
+
+var (
+	ErrBlockedAddress  = errors.New("destination address is not allowed")
+	ErrBodyTooLarge    = errors.New("response body exceeds size limit")
+	ErrContentType     = errors.New("unsupported content type")
+	ErrRobotsDisallow  = errors.New("disallowed by robots.txt")
+	ErrTooManyRedirect = errors.New("too many redirects")
+)
+
+// blockedPrefixes covers loopback, RFC 1918, CGNAT, link-local (including
+// cloud metadata endpoints), multicast and other non-public ranges.
+var blockedPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("10.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+	netip.MustParsePrefix("127.0.0.0/8"),
+	netip.MustParsePrefix("169.254.0.0/16"),
+	netip.MustParsePrefix("172.16.0.0/12"),
+	netip.MustParsePrefix("192.0.0.0/24"),
+	netip.MustParsePrefix("192.168.0.0/16"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+	netip.MustParsePrefix("224.0.0.0/4"),
+	netip.MustParsePrefix("240.0.0.0/4"),
+	netip.MustParsePrefix("::/128"),
+	netip.MustParsePrefix("::1/128"),
+	netip.MustParsePrefix("fc00::/7"),
+	netip.MustParsePrefix("fe80::/10"),
+	netip.MustParsePrefix("ff00::/8"),
+}
+
+// NAT64 and 6to4 addresses carry an IPv4 address that the network may
+// route to, so checkAddress judges them by that address.
+var (
+	nat64Prefix     = netip.MustParsePrefix("64:ff9b::/96")
+	sixToFourPrefix = netip.MustParsePrefix("2002::/16")
+)
+
+// Fetcher downloads article pages with timeouts, size and type limits, and
+// refuses to connect to internal addresses. Address checks run on the IP
+// actually dialled, so DNS rebinding and redirects cannot sidestep them.
+type Fetcher struct {
+	opts         FetcherOptions
+	client       *http.Client
+	allowHosts   map[string]bool
+	allowRanges  []netip.Prefix
+	robotsMu     sync.Mutex
+	robotsByHost map[string]robotsEntry
+}
+
+// FetcherOptionsFromConfig applies any configured overrides to the defaults.
+func FetcherOptionsFromConfig(Config types.Config) FetcherOptions {
+	opts := DefaultFetcherOptions()
+	if Config.FetchConnectTimeout > 0 {
+		opts.ConnectTimeout = Config.FetchConnectTimeout
+	}
+	if Config.FetchTimeout > 0 {
+		opts.Timeout = Config.FetchTimeout
+	}
+	if Config.FetchMaxBytes > 0 {
+		opts.MaxBodyBytes = Config.FetchMaxBytes
+	}
+	// 0 is a valid setting that disables redirects, and Config already
+	// defaults it to 5.
+	opts.MaxRedirects = Config.FetchMaxRedirects
+	if Config.FetchUserAgent != "" {
+		opts.UserAgent = Config.FetchUserAgent
+	}
+	opts.Allowlist = Config.FetchAllowlist
+	opts.RespectRobots = Config.FetchRespectRobots
+	return opts
+}
+
+func NewFetcher(opts FetcherOptions) *Fetcher {
+	f := &Fetcher{
+		opts:         opts,
+		allowHosts:   map[string]bool{},
+		robotsByHost: map[string]robotsEntry{},
+	}
+	for _, entry := range opts.Allowlist {
+		entry = strings.TrimSpace(entry)
+		if prefix, err := netip.ParsePrefix(entry); err == nil {
+			f.allowRanges = append(f.allowRanges, prefix)
+		} else if addr, err := netip.ParseAddr(entry); err == nil {
+			f.allowRanges = append(f.allowRanges, netip.PrefixFrom(addr, addr.BitLen()))
+		} else if entry != "" {
+			f.allowHosts[strings.ToLower(entry)] = true
+		}
+	}
+
+	dialer := &net.Dialer{Timeout: opts.ConnectTimeout}
+	transport := &http.Transport{
+		Proxy:                 nil,
+		TLSHandshakeTimeout:   opts.ConnectTimeout,
+		ResponseHeaderTimeout: opts.Timeout,
+		MaxIdleConnsPerHost:   2,
+		DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+			host, _, _ := net.SplitHostPort(addr)
+			if f.allowHosts[strings.ToLower(host)] {
+				return dialer.DialContext(ctx, network, addr)
+			}
+			guarded := *dialer
+			guarded.Control = func(_, address string, _ syscall.RawConn) error {
+				return f.checkAddress(address)
+			}
+			return guarded.DialContext(ctx, network, addr)
+		},
+	}
+	f.client = &http.Client{
+		Transport: transport,
+		Timeout:   opts.Timeout,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) > opts.MaxRedirects {
+				return ErrTooManyRedirect
+			}
+			if req.URL.Scheme != "http" && req.URL.Scheme != "https" {
+				return fmt.Errorf("redirect to unsupported scheme %q", req.URL.Scheme)
+			}
+			return nil
+		},
+	}
+	return f
+}
+
+func (f *Fetcher) checkAddress(address string) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return err
+	}
+	addr = addr.Unmap()
+	if v4, ok := embeddedIPv4(addr); ok {
+		addr = v4
+	}
+	for _, prefix := range f.allowRanges {
+		if prefix.Contains(addr) {
+			return nil
+		}
+	}
+	for _, prefix := range blockedPrefixes {
+		if prefix.Contains(addr) {
+			return fmt.Errorf("%w: %s", ErrBlockedAddress, addr)
+		}
+	}
+	return nil
+}
+
+// embeddedIPv4 returns the IPv4 address inside a NAT64 or 6to4 address.
+func embeddedIPv4(addr netip.Addr) (netip.Addr, bool) {
+	b := addr.As16()
+	switch {
+	case nat64Prefix.Contains(addr):
+		return netip.AddrFrom4([4]byte(b[12:16])), true
+	case sixToFourPrefix.Contains(addr):
+		return netip.AddrFrom4([4]byte(b[2:6])), true
+	}
+	return netip.Addr{}, false
+}
+
+// Fetch returns the body of rawURL as a string.
+func (f *Fetcher) Fetch(ctx context.Context, rawURL string) (string, error) {
+	return f.fetch(ctx, rawURL, nil)
+}
+
+// fetch is Fetch with every request, robots.txt included, first waiting
+// for its slot from limiter, which may be nil.
+func (f *Fetcher) fetch(ctx context.Context, rawURL string, limiter *hostLimiter) (string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid URL: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return "", fmt.Errorf("unsupported scheme %q", u.Scheme)
+	}
+	if f.opts.RespectRobots {
+		allowed, err := f.robotsAllowed(ctx, u, limiter)
+		if err != nil {
+			return "", err
+		}
+		if !allowed {
+			return "", ErrRobotsDisallow
+		}
+	}
+
+	if err := limiter.wait(ctx, u.String()); err != nil {
+		return "", err
+	}
+	body, contentType, err := f.get(ctx, u.String(), f.opts.MaxBodyBytes)
+	if err != nil {
+		return "", err
+	}
+	if !f.acceptedType(contentType) {
+		return "", fmt.Errorf("%w: %s", ErrContentType, contentType)
+	}
+	return string(body), nil
+}
+
+func (f *Fetcher) get(ctx context.Context, rawURL string, limit int64) ([]byte, string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, "", err
+	}
+	req.Header.Set("User-Agent", f.opts.UserAgent)
+	req.Header.Set("Accept", "text/html,application/xhtml+xml;q=0.9,text/plain;q=0.8")
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		return nil, "", &FetchStatusError{StatusCode: resp.StatusCode}
+	}
+	if limit > 0 && resp.ContentLength > limit {
+		return nil, "", ErrBodyTooLarge
+	}
+
+	reader := io.Reader(resp.Body)
+	if limit > 0 {
+		reader = io.LimitReader(resp.Body, limit+1)
+	}
+	body, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, "", err
+	}
+	if limit > 0 && int64(len(body)) > limit {
+		return nil, "", ErrBodyTooLarge
+	}
+	return body, resp.Header.Get("Content-Type"), nil
+}
+
+func (f *Fetcher) acceptedType(contentType string) bool {
+	if len(f.opts.ContentTypes) == 0 {
+		return true
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	for _, allowed := range f.opts.ContentTypes {
+		if strings.EqualFold(mediaType, allowed) {
+			return true
+		}
+	}
+	return false
+}
+
+type FetchStatusError struct {
+	StatusCode int
+}
+
+func (e *FetchStatusError) Error() string {
+	return fmt.Sprintf("unexpected status %d", e.StatusCode)
+}
+
+const robotsTTL = time.Hour
+
+type robotsEntry struct {
+	rules     robotsRules
+	fetchedAt time.Time
+}
+
+// robotsRules holds the Allow/Disallow path prefixes that apply to us.
+// Wildcards are not interpreted; rules match as plain prefixes.
+type robotsRules struct {
+	allow    []string
+	disallow []string
+}
+
+// allowed applies the longest matching rule, with Allow winning ties.
+func (r robotsRules) allowed(path string) bool {
+	best, allow := -1, true
+	for _, p := range r.disallow {
+		if strings.HasPrefix(path, p) && len(p) > best {
+			best, allow = len(p), false
+		}
+	}
+	for _, p := range r.allow {
+		if strings.HasPrefix(path, p) && len(p) >= best {
+			best, allow = len(p), true
+		}
+	}
+	return allow
+}
+
+func (f *Fetcher) robotsAllowed(ctx context.Context, u *url.URL, limiter *hostLimiter) (bool, error) {
+	key := u.Scheme + "://" + u.Host
+	f.robotsMu.Lock()
+	entry, ok := f.robotsByHost[key]
+	f.robotsMu.Unlock()
+
+	if !ok || time.Since(entry.fetchedAt) > robotsTTL {
+		if err := limiter.wait(ctx, key+"/robots.txt"); err != nil {
+			return false, err
+		}
+		entry = robotsEntry{fetchedAt: time.Now()}
+		// A missing or unreadable robots.txt allows everything.
+		if body, _, err := f.get(ctx, key+"/robots.txt", 512<<10); err == nil {
+			entry.rules = parseRobots(string(body), f.opts.UserAgent)
+		}
+		f.robotsMu.Lock()
+		f.robotsByHost[key] = entry
+		f.robotsMu.Unlock()
+	}
+
+	path := u.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	if u.RawQuery != "" {
+		path += "?" + u.RawQuery
+	}
+	return entry.rules.allowed(path), nil
+}
+
+// parseRobots keeps the rules from the group naming our product token, or
+// the "*" group when none does.
+func parseRobots(body, userAgent string) robotsRules {
+	token := strings.ToLower(strings.SplitN(userAgent, "/", 2)[0])
+	groups := map[string]*robotsRules{}
+	var current []string
+	inAgents := false
+
+	scanner := bufio.NewScanner(strings.NewReader(body))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = line[:i]
+		}
+		key, value, found := strings.Cut(line, ":")
+		if !found {
+			continue
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+
+		switch key {
+		case "user-agent":
+			if !inAgents {
+				current = nil
+			}
+			inAgents = true
+			agent := strings.ToLower(value)
+			current = append(current, agent)
+			if groups[agent] == nil {
+				groups[agent] = &robotsRules{}
+			}
+		case "allow", "disallow":
+			inAgents = false
+			if value == "" {
+				continue
+			}
+			for _, agent := range current {
+				if key == "allow" {
+					groups[agent].allow = append(groups[agent].allow, value)
+				} else {
+					groups[agent].disallow = append(groups[agent].disallow, value)
+				}
+			}
+		default:
+			inAgents = false
+		}
+	}
+
+	if rules, ok := groups[token]; ok {
+		return *rules
+	}
+	if rules, ok := groups["*"]; ok {
+		return *rules
+	}
+	return robotsRules{}
+}
@@ -0,0 +1,326 @@
+package pkg
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newTestFetcher allows the loopback address httptest listens on and
+// ignores robots.txt; edit may change either.
+func newTestFetcher(t *testing.T, edit func(*FetcherOptions)) *Fetcher {
+	t.Helper()
+	opts := DefaultFetcherOptions()
+	opts.Allowlist = []string{"127.0.0.1"}
+	opts.RespectRobots = false
+	if edit != nil {
+		edit(&opts)
+	}
+	return NewFetcher(opts)
+}
+
+func htmlHandler(body string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		fmt.Fprint(w, body)
+	}
+}
+
+func TestCheckAddress(t *testing.T) {
+	f := NewFetcher(DefaultFetcherOptions())
+	cases := []struct {
+		addr    string
+		blocked bool
+	}{
+		{"127.0.0.1", true},
+		{"10.1.2.3", true},
+		{"172.16.0.1", true},
+		{"192.168.1.1", true},
+		{"100.64.0.1", true},
+		{"169.254.169.254", true},
+		{"0.0.0.0", true},
+		{"::1", true},
+		{"fc00::1", true},
+		{"fe80::1", true},
+		{"::ffff:127.0.0.1", true},
+		{"64:ff9b::7f00:1", true},    // NAT64 for 127.0.0.1
+		{"64:ff9b::a9fe:a9fe", true}, // NAT64 for 169.254.169.254
+		{"2002:c0a8:101::1", true},   // 6to4 for 192.168.1.1
+		{"93.184.216.34", false},
+		{"2606:4700::1111", false},
+		{"64:ff9b::5db8:d822", false}, // NAT64 for 93.184.216.34
+		{"2002:5db8:d822::1", false},  // 6to4 for 93.184.216.34
+	}
+	for _, c := range cases {
+		err := f.checkAddress(netJoin(c.addr))
+		if blocked := errors.Is(err, ErrBlockedAddress); blocked != c.blocked {
+			t.Errorf("checkAddress(%s) = %v, want blocked %v", c.addr, err, c.blocked)
+		}
+	}
+}
+
+func netJoin(addr string) string {
+	if strings.Contains(addr, ":") {
+		return "[" + addr + "]:80"
+	}
+	return addr + ":80"
+}
+
+func TestFetchBlocksLoopback(t *testing.T) {
+	srv := httptest.NewServer(htmlHandler("<p>internal</p>"))
+	defer srv.Close()
+
+	f := newTestFetcher(t, func(o *FetcherOptions) { o.Allowlist = nil })
+	if _, err := f.Fetch(context.Background(), srv.URL); !errors.Is(err, ErrBlockedAddress) {
+		t.Fatalf("Fetch(%s) = %v, want ErrBlockedAddress", srv.URL, err)
+	}
+}
+
+func TestFetchAllowlist(t *testing.T) {
+	srv := httptest.NewServer(htmlHandler("<p>ok</p>"))
+	defer srv.Close()
+	u, _ := url.Parse(srv.URL)
+
+	for _, entry := range []string{"127.0.0.1", "127.0.0.0/8"} {
+		f := newTestFetcher(t, func(o *FetcherOptions) { o.Allowlist = []string{entry} })
+		if body, err := f.Fetch(context.Background(), srv.URL); err != nil || body != "<p>ok</p>" {
+			t.Errorf("allowlist %s: Fetch = %q, %v", entry, body, err)
+		}
+	}
+
+	byName := "http://localhost:" + u.Port()
+	f := newTestFetcher(t, func(o *FetcherOptions) { o.Allowlist = []string{"localhost"} })
+	if _, err := f.Fetch(context.Background(), byName); err != nil {
+		t.Errorf("allowlist localhost: Fetch(%s) = %v", byName, err)
+	}
+}
+
+func TestFetchRedirectToBlockedAddress(t *testing.T) {
+	internal := httptest.NewServer(htmlHandler("<p>secret</p>"))
+	defer internal.Close()
+	public := httptest.NewServer(http.RedirectHandler(internal.URL, http.StatusFound))
+	defer public.Close()
+
+	// Only the first hop is allowed, by name; the redirect goes to the
+	// loopback address itself.
+	u, _ := url.Parse(public.URL)
+	f := newTestFetcher(t, func(o *FetcherOptions) { o.Allowlist = []string{"localhost"} })
+	if _, err := f.Fetch(context.Background(), "http://localhost:"+u.Port()); !errors.Is(err, ErrBlockedAddress) {
+		t.Fatalf("Fetch = %v, want ErrBlockedAddress", err)
+	}
+}
+
+func TestFetchRedirectLimit(t *testing.T) {
+	hops := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hops++
+		http.Redirect(w, r, fmt.Sprintf("/hop/%d", hops), http.StatusFound)
+	}))
+	defer srv.Close()
+
+	for _, max := range []int{0, 2} {
+		hops = 0
+		f := newTestFetcher(t, func(o *FetcherOptions) { o.MaxRedirects = max })
+		if _, err := f.Fetch(context.Background(), srv.URL); !errors.Is(err, ErrTooManyRedirect) {
+			t.Errorf("MaxRedirects %d: Fetch = %v, want ErrTooManyRedirect", max, err)
+		}
+		if hops != max+1 {
+			t.Errorf("MaxRedirects %d: server saw %d requests, want %d", max, hops, max+1)
+		}
+	}
+
+	srv2 := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/" {
+			http.Redirect(w, r, "/final", http.StatusFound)
+			return
+		}
+		htmlHandler("<p>final</p>")(w, r)
+	}))
+	defer srv2.Close()
+	f := newTestFetcher(t, func(o *FetcherOptions) { o.MaxRedirects = 1 })
+	if body, err := f.Fetch(context.Background(), srv2.URL); err != nil || body != "<p>final</p>" {
+		t.Errorf("one redirect with MaxRedirects 1: Fetch = %q, %v", body, err)
+	}
+}
+
+func TestFetchBodySizeCap(t *testing.T) {
+	body := strings.Repeat("a", 100)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		if r.URL.Path == "/chunked" {
+			// Flushing before the body is written leaves Content-Length
+			// unset, so only the read limit can catch it.
+			w.(http.Flusher).Flush()
+		} else {
+			w.Header().Set("Content-Length", fmt.Sprint(len(body)))
+		}
+		fmt.Fprint(w, body)
+	}))
+	defer srv.Close()
+
+	f := newTestFetcher(t, func(o *FetcherOptions) { o.MaxBodyBytes = 10 })
+	for _, path := range []string{"/sized", "/chunked"} {
+		if _, err := f.Fetch(context.Background(), srv.URL+path); !errors.Is(err, ErrBodyTooLarge) {
+			t.Errorf("Fetch(%s) = %v, want ErrBodyTooLarge", path, err)
+		}
+	}
+
+	f = newTestFetcher(t, func(o *FetcherOptions) { o.MaxBodyBytes = int64(len(body)) })
+	for _, path := range []string{"/sized", "/chunked"} {
+		if got, err := f.Fetch(context.Background(), srv.URL+path); err != nil || got != body {
+			t.Errorf("Fetch(%s) at the limit = %d bytes, %v", path, len(got), err)
+		}
+	}
+}
+
+func TestFetchContentType(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", strings.TrimPrefix(r.URL.Path, "/type/"))
+		fmt.Fprint(w, "x")
+	}))
+	defer srv.Close()
+
+	f := newTestFetcher(t, nil)
+	cases := map[string]bool{
+		"text/html":                true,
+		"application/xhtml+xml":    true,
+		"text/plain":               true,
+		"application/octet-stream": false,
+		"application/pdf":          false,
+		"image/png":                false,
+	}
+	for contentType, ok := range cases {
+		_, err := f.Fetch(context.Background(), srv.URL+"/type/"+contentType)
+		if ok && err != nil {
+			t.Errorf("%s: Fetch = %v, want success", contentType, err)
+		}
+		if !ok && !errors.Is(err, ErrContentType) {
+			t.Errorf("%s: Fetch = %v, want ErrContentType", contentType, err)
+		}
+	}
+}
+
+func TestRobotsRules(t *testing.T) {
+	robots := `
+# comments and unknown fields are ignored
+User-agent: other
+Disallow: /
+
+User-agent: *
+Disallow: /private
+Allow: /private/public
+Disallow: /search?
+Crawl-delay: 5
+
+User-agent: sarjan
+Disallow: /drafts
+Allow: /drafts/published
+Disallow: /drafts/published/secret
+`
+	ours := parseRobots(robots, "SARJAN/1.0")
+	cases := map[string]bool{
+		"/":                          true,
+		"/private":                   true, // our group does not mention it
+		"/drafts":                    false,
+		"/drafts/x":                  false,
+		"/drafts/published":          true,
+		"/drafts/published/post":     true,
+		"/drafts/published/secret/x": false,
+	}
+	for path, want := range cases {
+		if got := ours.allowed(path); got != want {
+			t.Errorf("SARJAN allowed(%s) = %v, want %v", path, got, want)
+		}
+	}
+
+	star := parseRobots(robots, "SomeBot/2.0")
+	cases = map[string]bool{
+		"/":                    true,
+		"/private":             false,
+		"/private/x":           false,
+		"/private/public":      true,
+		"/private/public/page": true,
+		"/search?q=x":          false,
+		"/searching":           true,
+	}
+	for path, want := range cases {
+		if got := star.allowed(path); got != want {
+			t.Errorf("* allowed(%s) = %v, want %v", path, got, want)
+		}
+	}
+
+	tie := robotsRules{allow: []string{"/page"}, disallow: []string{"/page"}}
+	if !tie.allowed("/page") {
+		t.Error("Allow should win a tie with an equally long Disallow")
+	}
+}
+
+func TestFetchRespectsRobots(t *testing.T) {
+	robotsFetches := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/robots.txt" {
+			robotsFetches++
+			fmt.Fprint(w, "User-agent: *\nDisallow: /private\nAllow: /private/ok\n")
+			return
+		}
+		htmlHandler("<p>page</p>")(w, r)
+	}))
+	defer srv.Close()
+
+	f := newTestFetcher(t, func(o *FetcherOptions) { o.RespectRobots = true })
+	if _, err := f.Fetch(context.Background(), srv.URL+"/private/page"); !errors.Is(err, ErrRobotsDisallow) {
+		t.Errorf("Fetch(/private/page) = %v, want ErrRobotsDisallow", err)
+	}
+	for _, path := range []string{"/private/ok", "/news"} {
+		if _, err := f.Fetch(context.Background(), srv.URL+path); err != nil {
+			t.Errorf("Fetch(%s) = %v", path, err)
+		}
+	}
+	if robotsFetches != 1 {
+		t.Errorf("robots.txt fetched %d times, want once per host", robotsFetches)
+	}
+
+	// A missing robots.txt allows everything.
+	open := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/robots.txt" {
+			http.NotFound(w, r)
+			return
+		}
+		htmlHandler("<p>page</p>")(w, r)
+	}))
+	defer open.Close()
+	if _, err := f.Fetch(context.Background(), open.URL+"/private/page"); err != nil {
+		t.Errorf("Fetch without robots.txt = %v", err)
+	}
+}
+
+func TestFetchPacesRobotsRequests(t *testing.T) {
+	var times []time.Time
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		times = append(times, time.Now())
+		if r.URL.Path == "/robots.txt" {
+			fmt.Fprint(w, "User-agent: *\nAllow: /\n")
+			return
+		}
+		htmlHandler("<p>page</p>")(w, r)
+	}))
+	defer srv.Close()
+
+	const delay = 50 * time.Millisecond
+	f := newTestFetcher(t, func(o *FetcherOptions) { o.RespectRobots = true })
+	if _, err := f.fetch(context.Background(), srv.URL+"/a", newHostLimiter(delay)); err != nil {
+		t.Fatal(err)
+	}
+	if len(times) != 2 {
+		t.Fatalf("server saw %d requests, want robots.txt and the page", len(times))
+	}
+	if gap := times[1].Sub(times[0]); gap < delay-5*time.Millisecond {
+		t.Errorf("page fetched %v after robots.txt, want at least %v", gap, delay)
+	}
+}
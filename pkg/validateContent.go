@@ -0,0 +1,118 @@
+package pkg
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/iamlucif3r/sarjan/internal/types"
+	"github.com/kaptinlin/jsonrepair"
+)
+
+// ItemCount is the inclusive range of items the prompt asks for.
+type ItemCount struct {
+	Min int
+	Max int
+}
+
+// ContentRequirements mirrors the per-platform counts requested by the
+// generation prompt, keyed by ContentIdeas JSON field.
+var ContentRequirements = map[string]ItemCount{
+	"youtube_video_ideas": {Min: 2, Max: 2},
+	"twitter_posts":       {Min: 5, Max: 5},
+	"twitter_threads":     {Min: 1, Max: 2},
+	"linkedin_posts":      {Min: 1, Max: 1},
+	"instagram_reels":     {Min: 2, Max: 2},
+	"instagram_posts":     {Min: 2, Max: 2},
+}
+
+// MaxItemsPerField caps a caller-requested count so one request cannot
+// ask the model for an unbounded amount of output.
+const MaxItemsPerField = 20
+
+// ResolveRequirements turns a request's platforms and counts into the item
+// counts to generate. Every field of each named platform starts at its
+// ContentRequirements default, counts then override or add fields, and a
+// count of zero drops a field. With neither set, everything is generated.
+func ResolveRequirements(platforms []string, counts map[string]int) (map[string]ItemCount, error) {
+	reqs := map[string]ItemCount{}
+	if len(platforms) == 0 && len(counts) == 0 {
+		for field, count := range ContentRequirements {
+			reqs[field] = count
+		}
+		return reqs, nil
+	}
+	for _, platform := range platforms {
+		fields, ok := types.PlatformFields[strings.ToLower(strings.TrimSpace(platform))]
+		if !ok {
+			return nil, fmt.Errorf("unknown platform %q", platform)
+		}
+		for _, field := range fields {
+			reqs[field] = ContentRequirements[field]
+		}
+	}
+	for field, n := range counts {
+		if types.PlatformOf(field) == "" {
+			return nil, fmt.Errorf("unknown content field %q", field)
+		}
+		if n < 0 || n > MaxItemsPerField {
+			return nil, fmt.Errorf("count for %s must be between 0 and %d", field, MaxItemsPerField)
+		}
+		if n == 0 {
+			delete(reqs, field)
+			continue
+		}
+		reqs[field] = ItemCount{Min: n, Max: n}
+	}
+	if len(reqs) == 0 {
+		return nil, fmt.Errorf("request selects no content to generate")
+	}
+	return reqs, nil
+}
+
+// requirementsByPlatform splits reqs into one set per platform, in
+// types.Platforms order.
+func requirementsByPlatform(reqs map[string]ItemCount) ([]string, map[string]map[string]ItemCount) {
+	var order []string
+	split := map[string]map[string]ItemCount{}
+	for _, platform := range types.Platforms {
+		for _, field := range types.PlatformFields[platform] {
+			count, ok := reqs[field]
+			if !ok {
+				continue
+			}
+			if split[platform] == nil {
+				split[platform] = map[string]ItemCount{}
+				order = append(order, platform)
+			}
+			split[platform][field] = count
+		}
+	}
+	return order, split
+}
+
+// ContentGenerationError is returned when the model never produced a bundle
+// that parsed and satisfied ContentRequirements.
+type ContentGenerationError struct {
+	Attempts   int
+	Violations []string
+	LastOutput string
+}
+
+func (e *ContentGenerationError) Error() string {
+	return fmt.Sprintf("model output invalid after %d attempts: %s", e.Attempts, strings.Join(e.Violations, "; "))
+}
+
+// repairJSONOutput strips code fences and returns the output as valid JSON,
+// running it through jsonrepair when it is not.
+func repairJSONOutput(output string) ([]byte, error) {
+	output = stripCodeFences(output)
+	if json.Valid([]byte(output)) {
+		return []byte(output), nil
+	}
+	repaired, err := jsonrepair.JSONRepair(output)
+	if err != nil {
+		return nil, fmt.Errorf("output is not valid JSON and could not be repaired: %v", err)
+	}
+	return []byte(repaired), nil
+}